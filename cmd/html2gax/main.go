@@ -0,0 +1,194 @@
+/*
+Converts HTML into Go code which uses gax. Intended for migrating existing
+markup, such as static mockups, into gax. Reads a file, or stdin if no file is
+given, and writes gofmt-ed Go code to stdout. Usage:
+
+	go run github.com/mitranim/gax/cmd/html2gax -name=Page page.html > page.go
+
+Flags:
+
+	-pkg    Package name of the generated file. Default "main".
+	-name   Name of the generated function. Default "Render".
+	-bui    Generate `Bui.E` calls with closures, rather than nested `E` calls.
+	-xml    Parse the input as XML, preserving the case of names.
+	-space  Keep whitespace-only text between elements.
+
+By default, the generated function returns `gax.Bui` built from nested calls to
+`E`, `AP`, `Str` and `F`, matching the output of `Elem.GoString` and
+`Attrs.GoString`. With "-bui", the generated function takes `*gax.Bui` and
+uses `Bui.E`, with children as `func(*Bui)` closures, which are convenient for
+adding Go conditionals and loops.
+
+The generated file dot-imports gax, so that names are unqualified, matching
+`Elem.GoString`. Dot imports are file-scoped, so this doesn't affect other
+files in the package.
+
+Whitespace-only text which includes a line break, such as indentation, is
+dropped, and other runs of whitespace which include a line break are collapsed
+into a single space, except inside "pre", "textarea", "script" and "style".
+Use "-space" to keep all text verbatim.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mitranim/gax"
+	"github.com/mitranim/gax/cmd/internal/gen"
+)
+
+type opts struct {
+	Pkg   string
+	Name  string
+	Bui   bool
+	Xml   bool
+	Space bool
+}
+
+func main() {
+	var opt opts
+	flag.StringVar(&opt.Pkg, `pkg`, `main`, `package name of the generated file`)
+	flag.StringVar(&opt.Name, `name`, `Render`, `name of the generated function`)
+	flag.BoolVar(&opt.Bui, `bui`, false, `generate "Bui.E" calls with closures`)
+	flag.BoolVar(&opt.Xml, `xml`, false, `parse the input as XML`)
+	flag.BoolVar(&opt.Space, `space`, false, `keep whitespace-only text`)
+	flag.Parse()
+
+	src, err := read(flag.Args())
+	if err != nil {
+		fail(err)
+	}
+
+	out, err := convert(src, opt)
+	if err != nil {
+		fail(err)
+	}
+
+	_, err = os.Stdout.Write(out)
+	if err != nil {
+		fail(err)
+	}
+}
+
+func read(args []string) (string, error) {
+	switch len(args) {
+	case 0:
+		out, err := io.ReadAll(os.Stdin)
+		return string(out), err
+	case 1:
+		out, err := os.ReadFile(args[0])
+		return string(out), err
+	default:
+		return ``, fmt.Errorf(`expected at most one file, got %q`, args)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "[html2gax] %v\n", err)
+	os.Exit(1)
+}
+
+/*
+Parses the given markup and returns a formatted Go file. Formatting errors
+indicate a bug in this program, and include the unformatted source.
+*/
+func convert(src string, opt opts) ([]byte, error) {
+	var frag gax.Frag
+	if opt.Xml {
+		var err error
+		frag, err = gax.ParseXml(src)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		frag = gax.ParseHtml(src)
+	}
+
+	if !opt.Space {
+		frag = gen.TrimSpace(frag, false, nil)
+	}
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "package %v\n\n", opt.Pkg)
+	buf.WriteString("import . \"github.com/mitranim/gax\"\n\n")
+
+	if opt.Bui {
+		fmt.Fprintf(&buf, "func %v(bui *Bui) {\n", opt.Name)
+		writeStmts(&buf, frag)
+		buf.WriteString("}\n")
+	} else {
+		fmt.Fprintf(&buf, "func %v() Bui {\n\treturn F(\n", opt.Name)
+		writeArgs(&buf, frag)
+		buf.WriteString(")\n}\n")
+	}
+
+	out, err := format.Source([]byte(buf.String()))
+	if err != nil {
+		return nil, fmt.Errorf("can't format generated code: %w\n%v", err, buf.String())
+	}
+	return out, nil
+}
+
+// Writes children as multi-line call arguments for expression style.
+func writeArgs(buf *strings.Builder, vals []any) {
+	for _, val := range vals {
+		writeExpr(buf, val)
+		buf.WriteString(",\n")
+	}
+}
+
+func writeExpr(buf *strings.Builder, val any) {
+	elem, ok := val.(gax.Elem)
+	if !ok || isLeaf(elem) {
+		buf.WriteString(goString(val))
+		return
+	}
+
+	fmt.Fprintf(buf, "E(%v, %#v,\n", gen.Quote(elem.Tag), elem.Attrs)
+	writeArgs(buf, gen.Children(elem))
+	buf.WriteString(")")
+}
+
+// Writes children as statements for closure style.
+func writeStmts(buf *strings.Builder, vals []any) {
+	for _, val := range vals {
+		switch val := val.(type) {
+		case string:
+			fmt.Fprintf(buf, "bui.T(%v)\n", gen.Quote(val))
+
+		case gax.Elem:
+			if isLeaf(val) {
+				fmt.Fprintf(buf, "bui.%#v\n", val)
+				continue
+			}
+			fmt.Fprintf(buf, "bui.E(%v, %#v, func(bui *Bui) {\n", gen.Quote(val.Tag), val.Attrs)
+			writeStmts(buf, gen.Children(val))
+			buf.WriteString("})\n")
+
+		default:
+			fmt.Fprintf(buf, "bui.C(%#v)\n", val)
+		}
+	}
+}
+
+func goString(val any) string {
+	if val, ok := val.(string); ok {
+		return gen.Quote(val)
+	}
+	return fmt.Sprintf(`%#v`, val)
+}
+
+// Leaf elements don't contain other elements, and are written on one line.
+func isLeaf(val gax.Elem) bool {
+	for _, val := range gen.Children(val) {
+		if _, ok := val.(gax.Elem); ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+const testSrc = `<!doctype html>
+<html lang="en">
+  <body>
+    <!-- nav -->
+    <h1 class="title">Posts
+      here</h1>
+    <p>One <b>two</b> three</p>
+    <pre>
+  keep
+</pre>
+  </body>
+</html>
+`
+
+func TestConvert_expr(t *testing.T) {
+	test(t, testSrc, opts{Pkg: `main`, Name: `Page`}, "package main\n\nimport . \"github.com/mitranim/gax\"\n\nfunc Page() Bui {\n\treturn F(\n\t\tStr(Doctype),\n\t\tE(`html`, AP(`lang`, `en`),\n\t\t\tE(`body`, nil,\n\t\t\t\tComment(` nav `),\n\t\t\t\tE(`h1`, AP(`class`, `title`), `Posts here`),\n\t\t\t\tE(`p`, nil,\n\t\t\t\t\t`One `,\n\t\t\t\t\tE(`b`, nil, `two`),\n\t\t\t\t\t` three`,\n\t\t\t\t),\n\t\t\t\tE(`pre`, nil, \"  keep\\n\"),\n\t\t\t),\n\t\t),\n\t)\n}\n")
+}
+
+func TestConvert_bui(t *testing.T) {
+	test(t, testSrc, opts{Pkg: `views`, Name: `Page`, Bui: true}, "package views\n\nimport . \"github.com/mitranim/gax\"\n\nfunc Page(bui *Bui) {\n\tbui.C(Str(Doctype))\n\tbui.E(`html`, AP(`lang`, `en`), func(bui *Bui) {\n\t\tbui.E(`body`, nil, func(bui *Bui) {\n\t\t\tbui.C(Comment(` nav `))\n\t\t\tbui.E(`h1`, AP(`class`, `title`), `Posts here`)\n\t\t\tbui.E(`p`, nil, func(bui *Bui) {\n\t\t\t\tbui.T(`One `)\n\t\t\t\tbui.E(`b`, nil, `two`)\n\t\t\t\tbui.T(` three`)\n\t\t\t})\n\t\t\tbui.E(`pre`, nil, \"  keep\\n\")\n\t\t})\n\t})\n}\n")
+}
+
+func TestConvert_space(t *testing.T) {
+	test(t, "<p>\n  one\n</p>", opts{Pkg: `main`, Name: `Page`, Space: true}, "package main\n\nimport . \"github.com/mitranim/gax\"\n\nfunc Page() Bui {\n\treturn F(\n\t\tE(`p`, nil, \"\\n  one\\n\"),\n\t)\n}\n")
+}
+
+func TestConvert_names(t *testing.T) {
+	test(t, `<p a"b=1 =x>one</p>`, opts{Pkg: `main`, Name: `Page`}, "package main\n\nimport . \"github.com/mitranim/gax\"\n\nfunc Page() Bui {\n\treturn F(\n\t\tE(`p`, AP(`a\uFFFDb`, `1`, `\uFFFDx`, ``), `one`),\n\t)\n}\n")
+}
+
+func TestConvert_xml(t *testing.T) {
+	test(t, `<svg viewBox="0 0 1 1"><path d="M0"/></svg>`, opts{Pkg: `main`, Name: `Icon`, Xml: true}, "package main\n\nimport . \"github.com/mitranim/gax\"\n\nfunc Icon() Bui {\n\treturn F(\n\t\tE(`svg`, AP(`viewBox`, `0 0 1 1`),\n\t\t\tE(`path`, AP(`d`, `M0`)),\n\t\t),\n\t)\n}\n")
+
+	_, err := convert(`<one>`, opts{Pkg: `main`, Name: `Page`, Xml: true})
+	if err == nil {
+		t.Fatal(`expected error for malformed XML`)
+	}
+}
+
+func test(t testing.TB, src string, opt opts, exp string) {
+	t.Helper()
+	out, err := convert(src, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eq(t, string(out), exp)
+}
+
+func eq(t testing.TB, act, exp string) {
+	t.Helper()
+	if act != exp {
+		t.Fatalf("\nactual:\n%v\nexpected:\n%v", act, exp)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Internal utilities shared by the code generators in "cmd".
+*/
+package gen
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mitranim/gax"
+)
+
+// Quotes a string as a Go literal, preferring backquotes, like `Elem.GoString`.
+func Quote(val string) string {
+	if strconv.CanBackquote(val) {
+		return "`" + val + "`"
+	}
+	return strconv.Quote(val)
+}
+
+// Returns the children of an element produced by `gax.ParseHtml`.
+func Children(val gax.Elem) []any {
+	out, _ := val.Child.([]any)
+	return out
+}
+
+/*
+Removes insignificant whitespace from parsed markup: whitespace-only text
+which includes a line break, such as indentation, is dropped, and other runs of
+whitespace which include a line break are collapsed into a single space. Text
+inside "pre", "textarea", "script" and "style" is preserved. If `sep` is
+provided, text is processed separately between the matching characters, as if
+they were elements.
+*/
+func TrimSpace(vals []any, pre bool, sep func(rune) bool) (out []any) {
+	for _, val := range vals {
+		switch val := val.(type) {
+		case string:
+			if !pre {
+				val = trimText(val, sep)
+				if val == `` {
+					continue
+				}
+			}
+			out = append(out, val)
+
+		case gax.Elem:
+			child := TrimSpace(Children(val), pre || isPre(val.Tag), sep)
+			if child == nil {
+				val.Child = nil
+			} else {
+				val.Child = child
+			}
+			out = append(out, val)
+
+		default:
+			out = append(out, val)
+		}
+	}
+	return out
+}
+
+func trimText(src string, sep func(rune) bool) string {
+	if sep != nil {
+		if ind := strings.IndexFunc(src, sep); ind >= 0 {
+			_, size := utf8.DecodeRuneInString(src[ind:])
+			return trimText(src[:ind], nil) + src[ind:ind+size] + trimText(src[ind+size:], sep)
+		}
+	}
+
+	src = CollapseSpace(src)
+	if strings.TrimSpace(src) == `` && strings.Contains(src, "\n") {
+		return ``
+	}
+	return src
+}
+
+/*
+Collapses each run of whitespace which includes a line break into a single
+space. Whitespace-only text which includes a line break is preserved as "\n",
+allowing the caller to drop it.
+*/
+func CollapseSpace(src string) string {
+	var buf strings.Builder
+	for len(src) > 0 {
+		ind := strings.IndexFunc(src, isSpace)
+		if ind < 0 {
+			buf.WriteString(src)
+			break
+		}
+		buf.WriteString(src[:ind])
+		src = src[ind:]
+
+		end := strings.IndexFunc(src, isNotSpace)
+		if end < 0 {
+			end = len(src)
+		}
+
+		space := src[:end]
+		src = src[end:]
+
+		if !strings.Contains(space, "\n") {
+			buf.WriteString(space)
+		} else if buf.Len() == 0 && len(src) == 0 {
+			buf.WriteString("\n")
+		} else {
+			buf.WriteString(` `)
+		}
+	}
+	return buf.String()
+}
+
+func isPre(tag string) bool {
+	switch strings.ToLower(tag) {
+	case `pre`, `textarea`, `script`, `style`:
+		return true
+	default:
+		return false
+	}
+}
+
+func isSpace(char rune) bool {
+	return char == ' ' || char == '\t' || char == '\n' || char == '\r' || char == '\f'
+}
+
+func isNotSpace(char rune) bool { return !isSpace(char) }
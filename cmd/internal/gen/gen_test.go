@@ -0,0 +1,32 @@
+package gen
+
+import "testing"
+
+func TestCollapseSpace(t *testing.T) {
+	test := func(src, exp string) {
+		t.Helper()
+		if act := CollapseSpace(src); act != exp {
+			t.Fatalf(`CollapseSpace(%q): expected %q, got %q`, src, exp, act)
+		}
+	}
+
+	test(``, ``)
+	test(`one  two`, `one  two`)
+	test("one\n  two", `one two`)
+	test("\n  one\n", ` one `)
+	test("\n  ", "\n")
+	test(` `, ` `)
+}
+
+func TestQuote(t *testing.T) {
+	test := func(src, exp string) {
+		t.Helper()
+		if act := Quote(src); act != exp {
+			t.Fatalf(`Quote(%q): expected %v, got %v`, src, exp, act)
+		}
+	}
+
+	test(`one`, "`one`")
+	test("one\n", `"one\n"`)
+	test("one`", "\"one`\"")
+}
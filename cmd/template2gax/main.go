@@ -0,0 +1,996 @@
+/*
+Converts "text/template" and "html/template" sources into Go code which uses
+gax. Intended for migrating existing templates. Reads a file, or stdin if no
+file is given, and writes gofmt-ed Go code to stdout. Usage:
+
+	go run github.com/mitranim/gax/cmd/template2gax -type=PageDat page.html > page.go
+
+Flags:
+
+	-pkg    Package name of the generated file. Default "main".
+	-name   Name of the function generated for the main template. Default "Render".
+	-type   Type of the data parameter, such as "PageDat". Required.
+	-space  Keep whitespace-only text between elements.
+
+Each template becomes a function which takes `*gax.Bui` and the data parameter:
+the main template uses "-name", and each "{{define}}" or "{{block}}" becomes an
+unexported function named after the template, such as "renderNavHtml" for
+"nav.html", invoked where "{{template}}" was used. Markup becomes calls to
+`Bui.E`, with children as `func(*Bui)` closures where needed. "{{if}}",
+"{{range}}" and "{{with}}" become Go "if" and "for" statements, variables become
+Go variables, and pipelines become Go expressions, with built-in functions such
+as "eq", "and", "len", "index", "printf" mapped to Go operators and stdlib.
+Other functions are called by name, and must be defined by the user.
+
+The translation is syntactic: the data type is not inspected. Conditions are
+translated as-is, assuming booleans; non-boolean conditions, such as strings or
+slices, must be adjusted by hand, and the compiler will point them out. Values
+in attributes are assumed to be strings.
+
+Constructs which can't be translated faithfully are reported on stderr, and
+marked in the generated code with comments starting with "TODO(template2gax)".
+In particular, when markup in a template body is not balanced, for example
+when an element is opened in one template and closed in another, or when
+actions are used in tag or attribute names, the body is translated as raw
+markup written via `Bui.NonEscString`, which is correct but doesn't benefit
+from gax. In raw markup, values are interpolated only into text, where they're
+escaped via `Bui.C`. Values in other places, such as attributes, comments, or
+"script" and "style" elements, are not converted: they're evaluated and
+discarded, and each is marked and reported, to be written by hand.
+
+Unlike "text/template", gax escapes text and attribute values. Unlike
+"html/template", this escaping is not contextual: values interpolated into
+"script" and "style" elements are not escaped; such cases are reported.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template/parse"
+	"unicode"
+
+	"github.com/mitranim/gax"
+	"github.com/mitranim/gax/cmd/internal/gen"
+)
+
+type opts struct {
+	Pkg   string
+	Name  string
+	Type  string
+	Space bool
+}
+
+func main() {
+	var opt opts
+	flag.StringVar(&opt.Pkg, `pkg`, `main`, `package name of the generated file`)
+	flag.StringVar(&opt.Name, `name`, `Render`, `name of the function for the main template`)
+	flag.StringVar(&opt.Type, `type`, ``, `type of the data parameter (required)`)
+	flag.BoolVar(&opt.Space, `space`, false, `keep whitespace-only text`)
+	flag.Parse()
+
+	if opt.Type == `` {
+		fail(fmt.Errorf(`missing flag -type: the type of the data parameter, such as "-type=PageDat"`))
+	}
+
+	name, src, err := read(flag.Args())
+	if err != nil {
+		fail(err)
+	}
+
+	out, warns, err := convert(name, src, opt)
+	if err != nil {
+		fail(err)
+	}
+
+	for _, val := range warns {
+		fmt.Fprintf(os.Stderr, "[template2gax] %v\n", val)
+	}
+
+	_, err = os.Stdout.Write(out)
+	if err != nil {
+		fail(err)
+	}
+}
+
+func read(args []string) (string, string, error) {
+	switch len(args) {
+	case 0:
+		out, err := io.ReadAll(os.Stdin)
+		return `main`, string(out), err
+	case 1:
+		out, err := os.ReadFile(args[0])
+		return filepath.Base(args[0]), string(out), err
+	default:
+		return ``, ``, fmt.Errorf(`expected at most one file, got %q`, args)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "[template2gax] %v\n", err)
+	os.Exit(1)
+}
+
+/*
+Parses the given template source and returns a formatted Go file, along with
+warnings about constructs which couldn't be translated faithfully.
+*/
+func convert(name, src string, opt opts) ([]byte, []string, error) {
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck
+	trees := map[string]*parse.Tree{}
+
+	_, err := tree.Parse(src, ``, ``, trees)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conv := generator{opt: opt, trees: trees}
+	body := conv.file(name)
+
+	out, err := format.Source([]byte(body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't format generated code: %w\n%v", err, body)
+	}
+	return out, conv.warns, nil
+}
+
+type generator struct {
+	opt     opts
+	trees   map[string]*parse.Tree
+	buf     strings.Builder
+	warns   []string
+	imports map[string]bool
+	tpl     string
+	raw     strings.Builder
+	dot     []string
+	vals    int
+	loop    bool
+}
+
+func (self *generator) file(main string) string {
+	names := make([]string, 0, len(self.trees))
+	for name, tree := range self.trees {
+		if name != main && !parse.IsEmptyTree(tree.Root) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	if tree := self.trees[main]; tree != nil && !parse.IsEmptyTree(tree.Root) {
+		self.fun(self.opt.Name, tree)
+	}
+	for _, name := range names {
+		self.fun(funcName(name), self.trees[name])
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "package %v\n\n", self.opt.Pkg)
+	if len(self.imports) == 0 {
+		out.WriteString("import . \"github.com/mitranim/gax\"\n\n")
+	} else {
+		out.WriteString("import (\n")
+		for _, path := range []string{`fmt`, `net/url`} {
+			if self.imports[path] {
+				fmt.Fprintf(&out, "%q\n", path)
+			}
+		}
+		out.WriteString("\n. \"github.com/mitranim/gax\"\n)\n\n")
+	}
+	out.WriteString(self.buf.String())
+	return out.String()
+}
+
+func (self *generator) fun(name string, tree *parse.Tree) {
+	self.tpl = tree.Name
+	self.raw.Reset()
+	self.dot = []string{`dat`}
+	self.vals = 0
+	self.loop = false
+
+	fmt.Fprintf(&self.buf, "func %v(bui *Bui, dat %v) {\n", name, self.opt.Type)
+	self.list(tree.Root, false)
+	self.buf.WriteString("}\n\n")
+}
+
+func (self *generator) warn(node parse.Node, msg string, args ...any) {
+	msg = fmt.Sprintf(msg, args...)
+	self.warns = append(self.warns, fmt.Sprintf(`%v: %v`, self.tpl, msg))
+	fmt.Fprintf(&self.buf, "// TODO(template2gax): %v", msg)
+	if node != nil {
+		fmt.Fprintf(&self.buf, ": %v", strings.ReplaceAll(node.String(), "\n", ` `))
+	}
+	self.buf.WriteString("\n")
+}
+
+/*
+Translates a list of template nodes. Text nodes are joined, with other nodes
+replaced by markers, and parsed as HTML. If the markup is balanced, and the
+markers occur only in text and attribute values, the result is translated into
+`Bui.E` calls. Otherwise, the list is translated as raw markup.
+*/
+func (self *generator) list(list *parse.ListNode, raw bool) {
+	if list == nil {
+		return
+	}
+	if raw {
+		self.rawList(list)
+		return
+	}
+
+	var src strings.Builder
+	var nodes []parse.Node
+	for _, node := range list.Nodes {
+		if text, ok := node.(*parse.TextNode); ok {
+			src.Write(text.Text)
+		} else {
+			src.WriteRune(marker(len(nodes)))
+			nodes = append(nodes, node)
+		}
+	}
+
+	frag := []any(gax.ParseHtml(src.String()))
+	if !self.opt.Space {
+		frag = gen.TrimSpace(frag, false, isMarker)
+	}
+
+	if reason := unstructured(src.String(), frag, nodes); reason != `` {
+		self.warn(nil, `%v; translated as raw markup`, reason)
+		self.rawList(list)
+		return
+	}
+	self.stmts(frag, nodes)
+}
+
+func (self *generator) rawList(list *parse.ListNode) {
+	for _, node := range list.Nodes {
+		if text, ok := node.(*parse.TextNode); ok {
+			fmt.Fprintf(&self.buf, "bui.NonEscString(%v)\n", gen.Quote(string(text.Text)))
+			self.raw.Write(text.Text)
+		} else {
+			self.node(node, true)
+		}
+	}
+}
+
+// Writes parsed markup with markers as statements.
+func (self *generator) stmts(vals []any, nodes []parse.Node) {
+	for _, val := range vals {
+		switch val := val.(type) {
+		case string:
+			for _, part := range split(val) {
+				if part.node < 0 {
+					fmt.Fprintf(&self.buf, "bui.T(%v)\n", gen.Quote(part.text))
+				} else {
+					self.node(nodes[part.node], false)
+				}
+			}
+
+		case gax.Elem:
+			self.elem(val, nodes)
+
+		default:
+			fmt.Fprintf(&self.buf, "bui.C(%#v)\n", val)
+		}
+	}
+}
+
+func (self *generator) elem(val gax.Elem, nodes []parse.Node) {
+	attrs := self.attrs(val.Attrs, nodes)
+	child := gen.Children(val)
+
+	if isRawText(val.Tag) && hasMarker(child) {
+		self.warn(nil, `value interpolated into %q is not escaped by gax`, val.Tag)
+	}
+
+	if args, ok := self.args(child, nodes); ok {
+		fmt.Fprintf(&self.buf, "bui.E(%v, %v%v)\n", gen.Quote(val.Tag), attrs, args)
+		return
+	}
+
+	fmt.Fprintf(&self.buf, "bui.E(%v, %v, func(bui *Bui) {\n", gen.Quote(val.Tag), attrs)
+	loop := self.loop
+	self.loop = false
+	self.stmts(child, nodes)
+	self.loop = loop
+	self.buf.WriteString("})\n")
+}
+
+/*
+If the children consist only of text and output actions, returns them as call
+arguments, including the leading comma.
+*/
+func (self *generator) args(vals []any, nodes []parse.Node) (string, bool) {
+	var buf strings.Builder
+	for _, val := range vals {
+		text, ok := val.(string)
+		if !ok {
+			return ``, false
+		}
+
+		for _, part := range split(text) {
+			buf.WriteString(`, `)
+			if part.node < 0 {
+				buf.WriteString(gen.Quote(part.text))
+				continue
+			}
+
+			node, ok := nodes[part.node].(*parse.ActionNode)
+			if !ok || len(node.Pipe.Decl) > 0 {
+				return ``, false
+			}
+			buf.WriteString(self.pipe(node.Pipe))
+		}
+	}
+	return buf.String(), true
+}
+
+func (self *generator) attrs(vals gax.Attrs, nodes []parse.Node) string {
+	if vals == nil {
+		return `nil`
+	}
+
+	var buf strings.Builder
+	buf.WriteString(`AP(`)
+	for ind, val := range vals {
+		if ind > 0 {
+			buf.WriteString(`, `)
+		}
+		buf.WriteString(gen.Quote(val.Name()))
+		buf.WriteString(`, `)
+
+		parts := split(val.Value())
+		if len(parts) == 0 {
+			buf.WriteString("``")
+		}
+		for ind, part := range parts {
+			if ind > 0 {
+				buf.WriteString(` + `)
+			}
+			if part.node < 0 {
+				buf.WriteString(gen.Quote(part.text))
+			} else {
+				buf.WriteString(self.pipe(nodes[part.node].(*parse.ActionNode).Pipe))
+			}
+		}
+	}
+	buf.WriteString(`)`)
+	return buf.String()
+}
+
+func (self *generator) node(node parse.Node, raw bool) {
+	switch node := node.(type) {
+	case *parse.ActionNode:
+		self.action(node, raw)
+
+	case *parse.IfNode:
+		fmt.Fprintf(&self.buf, "if %v {\n", self.pipe(node.Pipe))
+		self.list(node.List, raw)
+		self.elseList(node.ElseList, raw)
+		self.buf.WriteString("}\n")
+
+	case *parse.RangeNode:
+		self.rangeNode(node, raw)
+
+	case *parse.WithNode:
+		var val string
+		if len(node.Pipe.Decl) > 0 {
+			val = varName(node.Pipe.Decl[0].Ident[0])
+		} else {
+			val = self.val()
+		}
+		src := self.pipe(&parse.PipeNode{Cmds: node.Pipe.Cmds})
+		fmt.Fprintf(&self.buf, "if %v := %v; %v != nil {\n", val, src, val)
+		self.warn(nil, `"with" assumes a nilable value; adjust the emptiness check to the type`)
+		self.dot = append(self.dot, val)
+		self.list(node.List, raw)
+		self.dot = self.dot[:len(self.dot)-1]
+		self.elseList(node.ElseList, raw)
+		self.buf.WriteString("}\n")
+
+	case *parse.TemplateNode:
+		self.template(node)
+
+	case *parse.BreakNode, *parse.ContinueNode:
+		if !self.loop {
+			self.warn(node, `can't translate inside an element closure`)
+			return
+		}
+		if _, ok := node.(*parse.BreakNode); ok {
+			self.buf.WriteString("break\n")
+		} else {
+			self.buf.WriteString("continue\n")
+		}
+
+	case *parse.CommentNode:
+
+	default:
+		self.warn(node, `can't translate`)
+	}
+}
+
+func (self *generator) elseList(list *parse.ListNode, raw bool) {
+	if list == nil {
+		return
+	}
+	if len(list.Nodes) == 1 {
+		if node, ok := list.Nodes[0].(*parse.IfNode); ok {
+			fmt.Fprintf(&self.buf, "} else if %v {\n", self.pipe(node.Pipe))
+			self.list(node.List, raw)
+			self.elseList(node.ElseList, raw)
+			return
+		}
+	}
+	self.buf.WriteString("} else {\n")
+	self.list(list, raw)
+}
+
+/*
+In raw markup, values are written only in text. Elsewhere, `Bui.C` would
+escape them incorrectly or not at all, so they're evaluated but discarded,
+leaving a TODO.
+*/
+func (self *generator) action(node *parse.ActionNode, raw bool) {
+	pipe := node.Pipe
+	if len(pipe.Decl) == 0 {
+		if ctx := self.rawContext(raw); ctx != `` {
+			self.warn(node, `can't interpolate into %v in raw markup; write it by hand`, ctx)
+			fmt.Fprintf(&self.buf, "_ = %v\n", self.pipe(pipe))
+			return
+		}
+		fmt.Fprintf(&self.buf, "bui.C(%v)\n", self.pipe(pipe))
+		return
+	}
+
+	op := `:=`
+	if pipe.IsAssign {
+		op = `=`
+	}
+	name := varName(pipe.Decl[0].Ident[0])
+	fmt.Fprintf(&self.buf, "%v %v %v\n", name, op, self.pipe(pipe))
+	if !pipe.IsAssign {
+		fmt.Fprintf(&self.buf, "_ = %v\n", name)
+	}
+}
+
+func (self *generator) rawContext(raw bool) string {
+	if !raw {
+		return ``
+	}
+	return rawContext(self.raw.String())
+}
+
+func (self *generator) rangeNode(node *parse.RangeNode, raw bool) {
+	pipe := node.Pipe
+	src := self.pipe(&parse.PipeNode{Cmds: pipe.Cmds})
+
+	var key, val string
+	switch len(pipe.Decl) {
+	case 0:
+		key, val = `_`, self.val()
+	case 1:
+		key = `_`
+		val = varName(pipe.Decl[0].Ident[0])
+	case 2:
+		key = varName(pipe.Decl[0].Ident[0])
+		val = varName(pipe.Decl[1].Ident[0])
+	}
+
+	if node.ElseList != nil {
+		fmt.Fprintf(&self.buf, "if len(%v) > 0 {\n", src)
+	}
+
+	fmt.Fprintf(&self.buf, "for %v, %v := range %v {\n", key, val, src)
+	loop := self.loop
+	self.loop = true
+	self.dot = append(self.dot, val)
+	self.list(node.List, raw)
+	self.dot = self.dot[:len(self.dot)-1]
+	self.loop = loop
+	self.buf.WriteString("}\n")
+
+	if node.ElseList != nil {
+		self.buf.WriteString("} else {\n")
+		self.list(node.ElseList, raw)
+		self.buf.WriteString("}\n")
+	}
+}
+
+func (self *generator) template(node *parse.TemplateNode) {
+	arg := `nil`
+	if node.Pipe != nil {
+		arg = self.pipe(node.Pipe)
+	}
+	if arg != `dat` {
+		self.warn(nil, `data passed to template %q may not match type %v`, node.Name, self.opt.Type)
+	}
+	if _, ok := self.trees[node.Name]; !ok {
+		self.warn(nil, `template %q is not defined in this source`, node.Name)
+	}
+	fmt.Fprintf(&self.buf, "%v(bui, %v)\n", funcName(node.Name), arg)
+}
+
+func (self *generator) val() string {
+	self.vals++
+	if self.vals == 1 {
+		return `val`
+	}
+	return fmt.Sprintf(`val%v`, self.vals)
+}
+
+func (self *generator) pipe(pipe *parse.PipeNode) string {
+	var out string
+	for ind, cmd := range pipe.Cmds {
+		if ind == 0 {
+			out = self.cmd(cmd, ``)
+		} else {
+			out = self.cmd(cmd, out)
+		}
+	}
+	return out
+}
+
+// The final argument is the result of the previous command in a pipeline.
+func (self *generator) cmd(cmd *parse.CommandNode, final string) string {
+	args := make([]string, 0, len(cmd.Args))
+	for _, arg := range cmd.Args[1:] {
+		args = append(args, self.arg(arg))
+	}
+	if final != `` {
+		args = append(args, final)
+	}
+
+	switch head := cmd.Args[0].(type) {
+	case *parse.IdentifierNode:
+		return self.call(head.Ident, args)
+	case *parse.FieldNode, *parse.ChainNode, *parse.VariableNode:
+		if len(args) > 0 {
+			return self.arg(head) + `(` + strings.Join(args, `, `) + `)`
+		}
+	}
+	return self.arg(cmd.Args[0])
+}
+
+func (self *generator) arg(node parse.Node) string {
+	switch node := node.(type) {
+	case *parse.DotNode:
+		return self.dot[len(self.dot)-1]
+	case *parse.FieldNode:
+		return self.dot[len(self.dot)-1] + `.` + strings.Join(node.Ident, `.`)
+	case *parse.VariableNode:
+		return strings.Join(append([]string{varName(node.Ident[0])}, node.Ident[1:]...), `.`)
+	case *parse.ChainNode:
+		return `(` + self.arg(node.Node) + `).` + strings.Join(node.Field, `.`)
+	case *parse.PipeNode:
+		return `(` + self.pipe(node) + `)`
+	case *parse.IdentifierNode:
+		return self.call(node.Ident, nil)
+	case *parse.StringNode:
+		return gen.Quote(node.Text)
+	case *parse.NumberNode:
+		return node.Text
+	case *parse.BoolNode:
+		return fmt.Sprint(node.True)
+	case *parse.NilNode:
+		return `nil`
+	default:
+		return node.String()
+	}
+}
+
+func (self *generator) call(name string, args []string) string {
+	join := func(sep string) string { return `(` + strings.Join(args, sep) + `)` }
+
+	switch name {
+	case `and`:
+		return join(` && `)
+	case `or`:
+		return join(` || `)
+	case `not`:
+		if len(args) == 1 && !strings.ContainsAny(args[0], ` `) {
+			return `!` + args[0]
+		}
+		return `!` + join(``)
+	case `eq`:
+		if len(args) > 2 {
+			var buf []string
+			for _, val := range args[1:] {
+				buf = append(buf, args[0]+` == `+val)
+			}
+			return `(` + strings.Join(buf, ` || `) + `)`
+		}
+		return join(` == `)
+	case `ne`:
+		return join(` != `)
+	case `lt`:
+		return join(` < `)
+	case `le`:
+		return join(` <= `)
+	case `gt`:
+		return join(` > `)
+	case `ge`:
+		return join(` >= `)
+	case `len`:
+		return `len` + join(`, `)
+	case `index`:
+		if len(args) == 0 {
+			break
+		}
+		out := args[0]
+		for _, val := range args[1:] {
+			out += `[` + val + `]`
+		}
+		return out
+	case `slice`:
+		if len(args) == 0 {
+			break
+		}
+		return args[0] + `[` + strings.Join(args[1:], `:`) + `]`
+	case `print`, `printf`, `println`:
+		self.use(`fmt`)
+		return `fmt.S` + name + join(`, `)
+	case `urlquery`:
+		self.use(`fmt`)
+		self.use(`net/url`)
+		return `url.QueryEscape(fmt.Sprint` + join(`, `) + `)`
+	case `html`:
+		if len(args) == 1 {
+			return args[0]
+		}
+	case `call`:
+		if len(args) > 0 {
+			return args[0] + `(` + strings.Join(args[1:], `, `) + `)`
+		}
+	case `js`:
+		self.warn(nil, `function "js" has no gax equivalent`)
+	}
+	return name + join(`, `)
+}
+
+func (self *generator) use(path string) {
+	if self.imports == nil {
+		self.imports = map[string]bool{}
+	}
+	self.imports[path] = true
+}
+
+const markerBase = '\uE000'
+
+func marker(ind int) rune { return markerBase + rune(ind) }
+
+func isMarker(char rune) bool { return char >= markerBase && char <= '\uF8FF' }
+
+type part struct {
+	text string
+	node int
+}
+
+// Splits text into literal parts and markers.
+func split(src string) (out []part) {
+	var buf strings.Builder
+	for _, char := range src {
+		if !isMarker(char) {
+			buf.WriteRune(char)
+			continue
+		}
+		if buf.Len() > 0 {
+			out = append(out, part{buf.String(), -1})
+			buf.Reset()
+		}
+		out = append(out, part{node: int(char - markerBase)})
+	}
+	if buf.Len() > 0 {
+		out = append(out, part{buf.String(), -1})
+	}
+	return
+}
+
+func hasMarker(vals []any) bool {
+	for _, val := range vals {
+		if val, ok := val.(string); ok && strings.IndexFunc(val, isMarker) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Returns a reason why the parsed markup can't be translated structurally, or an
+empty string if it can.
+*/
+func unstructured(src string, frag []any, nodes []parse.Node) string {
+	if !balanced(src) {
+		return `markup is not balanced`
+	}
+	return unstructuredIn(frag, nodes)
+}
+
+func unstructuredIn(vals []any, nodes []parse.Node) string {
+	for _, val := range vals {
+		switch val := val.(type) {
+		case gax.Elem:
+			if strings.IndexFunc(val.Tag, isMarker) >= 0 {
+				return `action in tag name`
+			}
+			for _, attr := range val.Attrs {
+				if strings.IndexFunc(attr.Name(), isMarker) >= 0 {
+					return `action in attribute list`
+				}
+				for _, part := range split(attr.Value()) {
+					if part.node < 0 {
+						continue
+					}
+					node, ok := nodes[part.node].(*parse.ActionNode)
+					if !ok || len(node.Pipe.Decl) > 0 {
+						return `control structure in attribute value`
+					}
+				}
+			}
+			if reason := unstructuredIn(gen.Children(val), nodes); reason != `` {
+				return reason
+			}
+
+		case gax.Comment:
+			if strings.IndexFunc(string(val), isMarker) >= 0 {
+				return `action in comment`
+			}
+		}
+	}
+	return ``
+}
+
+/*
+Elements whose end tags may be omitted. Unclosed elements other than these
+indicate that markup spans several templates or branches.
+*/
+var optionalEnd = map[string]bool{
+	`body`: true, `caption`: true, `colgroup`: true, `dd`: true, `dt`: true,
+	`head`: true, `html`: true, `li`: true, `optgroup`: true, `option`: true,
+	`p`: true, `rb`: true, `rp`: true, `rt`: true, `rtc`: true, `tbody`: true,
+	`td`: true, `tfoot`: true, `th`: true, `thead`: true, `tr`: true,
+}
+
+/*
+Rough check for balanced markup. Stray end tags, and unclosed elements which
+require end tags, make the markup unbalanced. Void elements, comments, and the
+content of raw text elements are skipped.
+*/
+func balanced(src string) bool {
+	var stack []string
+
+	for len(src) > 0 {
+		ind := strings.IndexByte(src, '<')
+		if ind < 0 {
+			break
+		}
+		src = src[ind+1:]
+
+		if strings.HasPrefix(src, `!--`) {
+			end := strings.Index(src, `-->`)
+			if end < 0 {
+				return false
+			}
+			src = src[end+3:]
+			continue
+		}
+
+		end := src[0:0]
+		if strings.HasPrefix(src, `/`) {
+			end = src[:1]
+			src = src[1:]
+		}
+
+		name := tagName(src)
+		if name == `` {
+			continue
+		}
+		if close := strings.IndexByte(src, '>'); close >= 0 {
+			src = src[close+1:]
+		} else {
+			return false
+		}
+
+		if end != `` {
+			found := false
+			for ind := len(stack) - 1; ind >= 0; ind-- {
+				if stack[ind] == name {
+					stack, found = stack[:ind], true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+			continue
+		}
+
+		if gax.DialectHtml.IsVoid(name) {
+			continue
+		}
+		if isRawText(name) {
+			close := strings.Index(strings.ToLower(src), `</`+name)
+			if close < 0 {
+				return false
+			}
+			src = src[close:]
+		}
+		stack = append(stack, name)
+	}
+
+	for _, name := range stack {
+		if !optionalEnd[name] {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Rough description of the context at the end of the given raw markup, as
+understood by the HTML tokenizer: empty for text, or a name such as "attribute
+value". Control structures are not considered: text in all branches is
+treated as if it was sequential.
+*/
+func rawContext(src string) string {
+	const (
+		modeText = iota
+		modeTag
+		modeValue
+		modeComment
+		modeRaw
+	)
+
+	mode := modeText
+	var tag string
+	var quote byte
+
+	for ind := 0; ind < len(src); ind++ {
+		char := src[ind]
+
+		switch mode {
+		case modeText:
+			if char != '<' {
+				continue
+			}
+			if strings.HasPrefix(src[ind:], `<!--`) {
+				mode = modeComment
+				ind += 3
+				continue
+			}
+
+			start := ind + 1
+			if strings.HasPrefix(src[start:], `/`) {
+				start++
+			}
+			name := tagName(src[start:])
+			if name == `` {
+				continue
+			}
+
+			mode, tag = modeTag, name
+			if start > ind+1 {
+				tag = ``
+			}
+			ind = start + len(name) - 1
+
+		case modeComment:
+			if strings.HasPrefix(src[ind:], `-->`) {
+				mode = modeText
+				ind += 2
+			}
+
+		case modeTag, modeValue:
+			switch {
+			case mode == modeValue && quote != 0:
+				if char == quote {
+					mode = modeTag
+				}
+			case char == '>':
+				mode = modeText
+				if isRawText(tag) {
+					mode = modeRaw
+				}
+			case mode == modeValue && unicode.IsSpace(rune(char)):
+				mode = modeTag
+			case mode == modeTag && char == '=':
+				mode, quote = modeValue, 0
+				for ind+1 < len(src) && unicode.IsSpace(rune(src[ind+1])) {
+					ind++
+				}
+				if ind+1 < len(src) && (src[ind+1] == '"' || src[ind+1] == '\'') {
+					ind++
+					quote = src[ind]
+				}
+			}
+
+		case modeRaw:
+			rest := src[ind:]
+			if len(rest) >= len(tag)+2 && strings.EqualFold(rest[:len(tag)+2], `</`+tag) {
+				mode = modeText
+				ind += len(tag) + 1
+			}
+		}
+	}
+
+	switch mode {
+	case modeTag:
+		return `tag`
+	case modeValue:
+		return `attribute value`
+	case modeComment:
+		return `comment`
+	case modeRaw:
+		return fmt.Sprintf(`%q element`, tag)
+	default:
+		return ``
+	}
+}
+
+func tagName(src string) string {
+	end := strings.IndexFunc(src, func(char rune) bool {
+		return unicode.IsSpace(char) || char == '/' || char == '>'
+	})
+	if end < 0 {
+		end = len(src)
+	}
+	name := src[:end]
+	if name == `` || !isAsciiLetter(name[0]) {
+		return ``
+	}
+	return strings.ToLower(name)
+}
+
+func isRawText(tag string) bool {
+	switch strings.ToLower(tag) {
+	case `script`, `style`:
+		return true
+	default:
+		return false
+	}
+}
+
+func isAsciiLetter(char byte) bool {
+	return (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')
+}
+
+// Converts a template variable such as "$post" to a Go identifier.
+func varName(src string) string {
+	if src == `$` {
+		return `dat`
+	}
+	return identifier(strings.TrimPrefix(src, `$`), false)
+}
+
+/*
+Converts a template name such as "site-top.html" to a Go identifier such as
+"renderSiteTopHtml". The prefix avoids collisions with variables.
+*/
+func funcName(src string) string { return `render` + identifier(src, true) }
+
+func identifier(src string, upper bool) string {
+	var buf strings.Builder
+	for _, char := range src {
+		if !unicode.IsLetter(char) && !unicode.IsDigit(char) && char != '_' {
+			upper = buf.Len() > 0
+			continue
+		}
+		if buf.Len() == 0 && unicode.IsDigit(char) {
+			buf.WriteString(`tpl`)
+		}
+		if upper {
+			char = unicode.ToUpper(char)
+			upper = false
+		}
+		buf.WriteRune(char)
+	}
+	if buf.Len() == 0 {
+		return `tpl`
+	}
+	return buf.String()
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvert_markup(t *testing.T) {
+	test(t, `
+<div class="one">
+	<h1>{{.Title}}</h1>
+	<a href="/posts/{{.Path}}" title="{{.Title}}">two</a>
+	<p>Hello {{.Name}}!</p>
+</div>
+`, `
+func Render(bui *Bui, dat Dat) {
+	bui.E(`+"`div`"+`, AP(`+"`class`, `one`"+`), func(bui *Bui) {
+		bui.E(`+"`h1`"+`, nil, dat.Title)
+		bui.E(`+"`a`"+`, AP(`+"`href`, `/posts/`+dat.Path, `title`, dat.Title), `two`"+`)
+		bui.E(`+"`p`"+`, nil, `+"`Hello `, dat.Name, `!`"+`)
+	})
+}
+`)
+}
+
+func TestConvert_control(t *testing.T) {
+	test(t, `
+<ul>
+	{{range .Posts}}
+		<li>{{if .Draft}}<i>{{.Title}}</i>{{else if eq .Kind "a" "b"}}{{.Title}}{{else}}none{{end}}</li>
+	{{else}}
+		<li>empty</li>
+	{{end}}
+</ul>
+{{range $ind, $val := .Tags}}{{if not $ind}}{{continue}}{{end}}{{$val}}{{end}}
+`, `
+func Render(bui *Bui, dat Dat) {
+	bui.E(`+"`ul`"+`, nil, func(bui *Bui) {
+		if len(dat.Posts) > 0 {
+			for _, val := range dat.Posts {
+				bui.E(`+"`li`"+`, nil, func(bui *Bui) {
+					if val.Draft {
+						bui.E(`+"`i`"+`, nil, val.Title)
+					} else if val.Kind == `+"`a`"+` || val.Kind == `+"`b`"+` {
+						bui.C(val.Title)
+					} else {
+						bui.T(`+"`none`"+`)
+					}
+				})
+			}
+		} else {
+			bui.E(`+"`li`"+`, nil, `+"`empty`"+`)
+		}
+	})
+	for ind, val := range dat.Tags {
+		if !ind {
+			continue
+		}
+		bui.C(val)
+	}
+}
+`)
+}
+
+func TestConvert_define(t *testing.T) {
+	out, warns := convertTest(t, `{{define "site-nav.html"}}<nav>{{.Path}}</nav>{{end}}{{template "site-nav.html" .}}{{template "site-nav.html" .Page}}`)
+
+	eq(t, out, `
+func Render(bui *Bui, dat Dat) {
+	renderSiteNavHtml(bui, dat)
+	// TODO(template2gax): data passed to template "site-nav.html" may not match type Dat
+	renderSiteNavHtml(bui, dat.Page)
+}
+
+func renderSiteNavHtml(bui *Bui, dat Dat) {
+	bui.E(`+"`nav`"+`, nil, dat.Path)
+}
+`)
+	eq(t, strings.Join(warns, "\n"), `main: data passed to template "site-nav.html" may not match type Dat`)
+}
+
+func TestConvert_funcs(t *testing.T) {
+	test(t, `{{$n := len .Items}}{{printf "%d" $n}}{{index .M "k" 1}}{{.Fn 1 "two"}}{{.X | custom}}{{html .Y}}{{call .F 1}}{{$n = 2}}`, `
+func Render(bui *Bui, dat Dat) {
+	n := len(dat.Items)
+	_ = n
+	bui.C(fmt.Sprintf(`+"`%d`"+`, n))
+	bui.C(dat.M[`+"`k`"+`][1])
+	bui.C(dat.Fn(1, `+"`two`"+`))
+	bui.C(custom(dat.X))
+	bui.C(dat.Y)
+	bui.C(dat.F(1))
+	n = 2
+}
+`)
+}
+
+func TestConvert_raw(t *testing.T) {
+	out, warns := convertTest(t, `{{define "top"}}<html><body class="{{if .Dark}}dark{{end}}">{{end}}{{template "top" .}}</body></html>`)
+
+	eq(t, out, `
+func Render(bui *Bui, dat Dat) {
+	// TODO(template2gax): markup is not balanced; translated as raw markup
+	renderTop(bui, dat)
+	bui.NonEscString(`+"`</body></html>`"+`)
+}
+
+func renderTop(bui *Bui, dat Dat) {
+	// TODO(template2gax): control structure in attribute value; translated as raw markup
+	bui.NonEscString(`+"`<html><body class=\"`"+`)
+	if dat.Dark {
+		bui.NonEscString(`+"`dark`"+`)
+	}
+	bui.NonEscString(`+"`\">`"+`)
+}
+`)
+	eq(t, len(warns), 2)
+}
+
+func TestConvert_raw_context(t *testing.T) {
+	out, warns := convertTest(t, `{{define "top"}}<div title="{{.T}}" {{.A}}><p>{{.P}}</p><script>let one = {{.J}}</script><!-- {{.C}} -->{{end}}{{template "top" .}}</div>`)
+
+	eq(t, out, `
+func Render(bui *Bui, dat Dat) {
+	// TODO(template2gax): markup is not balanced; translated as raw markup
+	renderTop(bui, dat)
+	bui.NonEscString(`+"`</div>`"+`)
+}
+
+func renderTop(bui *Bui, dat Dat) {
+	// TODO(template2gax): markup is not balanced; translated as raw markup
+	bui.NonEscString(`+"`<div title=\"`"+`)
+	// TODO(template2gax): can't interpolate into attribute value in raw markup; write it by hand: {{.T}}
+	_ = dat.T
+	bui.NonEscString(`+"`\" `"+`)
+	// TODO(template2gax): can't interpolate into tag in raw markup; write it by hand: {{.A}}
+	_ = dat.A
+	bui.NonEscString(`+"`><p>`"+`)
+	bui.C(dat.P)
+	bui.NonEscString(`+"`</p><script>let one = `"+`)
+	// TODO(template2gax): can't interpolate into "script" element in raw markup; write it by hand: {{.J}}
+	_ = dat.J
+	bui.NonEscString(`+"`</script><!-- `"+`)
+	// TODO(template2gax): can't interpolate into comment in raw markup; write it by hand: {{.C}}
+	_ = dat.C
+	bui.NonEscString(`+"` -->`"+`)
+}
+`)
+	eq(t, len(warns), 6)
+}
+
+func TestRawContext(t *testing.T) {
+	test := func(src, exp string) {
+		t.Helper()
+		eq(t, rawContext(src), exp)
+	}
+
+	test(``, ``)
+	test(`one`, ``)
+	test(`<div>`, ``)
+	test(`<div>one</div>`, ``)
+	test(`<div`, `tag`)
+	test(`<div class="one" `, `tag`)
+	test(`<div class="`, `attribute value`)
+	test(`<div class='one`, `attribute value`)
+	test(`<div class=`, `attribute value`)
+	test(`<div class = one`, `attribute value`)
+	test(`<div class="one>two`, `attribute value`)
+	test(`<div class="one">`, ``)
+	test(`<a href=one>`, ``)
+	test(`<!-- <div> `, `comment`)
+	test(`<!-- <div> -->`, ``)
+	test(`<script>`, `"script" element`)
+	test(`<STYLE>a > b {`, `"style" element`)
+	test(`<script>"</div>"`, `"script" element`)
+	test(`<script></SCRIPT>`, ``)
+	test(`</div`, `tag`)
+	test(`a < b`, ``)
+}
+
+func TestBalanced(t *testing.T) {
+	test := func(src string, exp bool) {
+		t.Helper()
+		eq(t, balanced(src), exp)
+	}
+
+	test(``, true)
+	test(`<div><p>one<br></div>`, true)
+	test(`<ul><li>one<li>two</ul>`, true)
+	test(`<script>"</div>"</script>`, true)
+	test(`<!-- <div> -->`, true)
+	test(`<div>`, false)
+	test(`</div>`, false)
+	test(`<html><body>`, true)
+	test(`<div><span></div>`, true)
+}
+
+func test(t testing.TB, src, exp string) {
+	t.Helper()
+	out, _ := convertTest(t, src)
+	eq(t, out, exp)
+}
+
+// Returns the generated code after the imports.
+func convertTest(t testing.TB, src string) (string, []string) {
+	t.Helper()
+	out, warns, err := convert(`main`, src, opts{Pkg: `main`, Name: `Render`, Type: `Dat`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	str := string(out)
+	return str[strings.Index(str, "\nfunc "):], warns
+}
+
+func eq[A comparable](t testing.TB, act, exp A) {
+	t.Helper()
+	if act != exp {
+		t.Fatalf("\nactual:\n%v\nexpected:\n%v", act, exp)
+	}
+}
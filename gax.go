@@ -14,7 +14,8 @@ Features / benefits:
 	* Benefit from static typing.
 	* Benefit from Go code analysis.
 	* Benefit from Go performance.
-	* Tiny and dependency-free.
+	* Tiny and dependency-free. Uses only the standard library, and imports
+	  "html/template" only to recognize its trusted string types.
 
 The API is bloated with "just in case" public exports, but 99% of what you want
 is `E`, `F`, `Bui`, and `Bui.E`. See the `Bui` example below.
@@ -27,6 +28,13 @@ document-level HTML builder, or `Str(Doctype)` to prepend this in `F`.
 */
 const Doctype = `<!doctype html>`
 
+/*
+Shortcut for prepending an XML declaration, for XML documents rendered with
+`Ctx.Xml`. Use `Bui(XmlDecl)` to create a document-level XML builder, or
+`Str(XmlDecl)` to prepend this in `F`.
+*/
+const XmlDecl = `<?xml version="1.0" encoding="UTF-8"?>`
+
 /*
 Short for "renderer". On children implementing this interface, the `Render`
 method is called for side effects, instead of stringifying the child.
@@ -42,10 +50,23 @@ type Str string
 // Implement `Ren`. Appends itself without HTML/XML escaping.
 func (self Str) Render(bui *Bui) { bui.NonEscString(string(self)) }
 
+/*
+Implement `fmt.GoStringer` for debug purposes. Not used by builder methods.
+Represents itself as a conversion to `Str`, using `Doctype` when possible.
+*/
+func (self Str) GoString() string {
+	if self == Doctype {
+		return `Str(Doctype)`
+	}
+	return `Str(` + string(appendQuote(nil, string(self))) + `)`
+}
+
 /*
 Set of known HTML boolean attributes. Can be modified via `Bool.Add` and
 `Bool.Del`. The specification postulates the concept, but where's the standard
-list? Taken from non-authoritative sources. Reference:
+list? Taken from non-authoritative sources. Used only when rendering without a
+`Dialect`; modifying this affects every such render in the program, and is a
+data race during concurrent renders. Prefer `Dialect`. Reference:
 
 	https://www.w3.org/TR/html52/infrastructure.html#boolean-attribute
 */
@@ -59,7 +80,8 @@ var Bool = newStringSet(
 
 /*
 Set of known HTML void elements, also known as self-closing tags. Can be
-modified via `Void.Add` and `Void.Del`. Reference:
+modified via `Void.Add` and `Void.Del`. Used only when rendering without a
+`Dialect`, with the same caveats as `Bool`. Reference:
 
 	https://www.w3.org/TR/html52/
 	https://www.w3.org/TR/html52/syntax.html#writing-html-documents-elements
@@ -69,6 +91,47 @@ var Void = newStringSet(
 	`param`, `source`, `track`, `wbr`,
 )
 
+/*
+Set of known HTML raw text elements. Their content is not parsed as markup,
+and may not contain character references, so children of these elements are
+written without escaping, but must not contain sequences which would
+prematurely end the element. Can be modified via `Raw.Add` and `Raw.Del`.
+Used only when rendering without a `Dialect`, with the same caveats as `Bool`.
+See `Bui.E` for details. Reference:
+
+	https://html.spec.whatwg.org/multipage/syntax.html#raw-text-elements
+*/
+var Raw = newStringSet(`script`, `style`)
+
+/*
+Set of HTML elements which are formatted as blocks in pretty mode: placed on
+their own lines and indented, see `Ctx.Indent`. Includes block-level elements
+and document metadata. Whitespace around these elements doesn't affect how the
+document is rendered, as long as their parent is also a block. Other elements,
+including custom elements, are considered inline, and their content is left
+as-is. Can be modified via `Block.Add` and `Block.Del`. Reference:
+
+	https://developer.mozilla.org/en-US/docs/Web/HTML/Block-level_elements
+*/
+var Block = newStringSet(
+	`address`, `article`, `aside`, `base`, `blockquote`, `body`, `caption`,
+	`col`, `colgroup`, `dd`, `details`, `dialog`, `div`, `dl`, `dt`,
+	`fieldset`, `figcaption`, `figure`, `footer`, `form`, `h1`, `h2`, `h3`,
+	`h4`, `h5`, `h6`, `head`, `header`, `hgroup`, `hr`, `html`, `li`, `link`,
+	`main`, `menu`, `meta`, `nav`, `ol`, `p`, `pre`, `script`, `search`,
+	`section`, `style`, `summary`, `table`, `tbody`, `td`, `tfoot`, `th`,
+	`thead`, `title`, `tr`, `ul`,
+)
+
+/*
+Set of HTML elements whose content is whitespace-sensitive or not markup, and
+is never formatted, see `Ctx.Indent`. Can be modified via `Verbatim.Add` and
+`Verbatim.Del`.
+*/
+var Verbatim = newStringSet(
+	`listing`, `plaintext`, `pre`, `script`, `style`, `textarea`, `title`, `xmp`,
+)
+
 /*
 Short for "vacate", "vacuum", "vacuous". Takes a "child" intended for `E` or
 `F`. If the child is empty, returns `nil`, otherwise returns the child as-is.
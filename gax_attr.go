@@ -1,6 +1,12 @@
 package gax
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"time"
+)
 
 /*
 Short for "attributes". Same as the `Attrs{}` constructor, but uses parentheses,
@@ -39,7 +45,7 @@ Panics if the argument count is not even.
 */
 func (self Attrs) AP(pairs ...string) Attrs {
 	if len(pairs)%2 != 0 {
-		panic(fmt.Errorf(`[gax] Attrs.AP expects an even amount of args, got %#v`, pairs))
+		panic(PairError{`Attrs.AP`, pairs})
 	}
 
 	ind := 0
@@ -93,6 +99,89 @@ func (self Attrs) mut(key, val string, fun func(Attr, string) Attr) Attrs {
 	return append(self, Attr{key, val})
 }
 
+/*
+Returns the value of the first attribute with the given name, or an empty
+string if there's no such attribute. Browsers also use the first attribute
+when names are duplicated. For attributes created via `Flag`, returns "true"
+or "false".
+*/
+func (self Attrs) Get(key string) string {
+	if ind := self.index(key); ind >= 0 {
+		return self[ind].Value()
+	}
+	return ``
+}
+
+/*
+True if there's an attribute with the given name, regardless of its value.
+Zero attributes (`Attr{}`) are ignored.
+*/
+func (self Attrs) Has(key string) bool { return self.index(key) >= 0 }
+
+/*
+Returns a version without any attributes with the given name. If there are no
+such attributes, returns self as-is. Otherwise returns a new slice, without
+modifying the original.
+*/
+func (self Attrs) Del(key string) Attrs {
+	ind := self.index(key)
+	if ind < 0 {
+		return self
+	}
+
+	out := make(Attrs, ind, len(self)-1)
+	copy(out, self[:ind])
+	for _, val := range self[ind+1:] {
+		if val.Name() != key {
+			out = append(out, val)
+		}
+	}
+	return out
+}
+
+/*
+Returns a combination of self and the given attributes, where attributes
+present in both are combined via the strategies in `AttrMerges`, and other
+attributes are appended in order. Useful for components which accept
+attributes from callers. Zero attributes (`Attr{}`), such as `FlagUnset`, are
+skipped. Never modifies either input. Example:
+
+	AP(`class`, `btn`, `type`, `button`).Merge(AP(`class`, `btn-primary`, `type`, `submit`))
+	// AP(`class`, `btn btn-primary`, `type`, `submit`)
+*/
+func (self Attrs) Merge(vals Attrs) Attrs {
+	if len(vals) == 0 {
+		return self
+	}
+
+	out := make(Attrs, len(self), len(self)+len(vals))
+	copy(out, self)
+
+	for _, val := range vals {
+		if val == (Attr{}) {
+			continue
+		}
+		if ind := out.index(val.Name()); ind >= 0 {
+			out[ind] = mergeAttr(out[ind], val)
+		} else {
+			out = append(out, val)
+		}
+	}
+	return out
+}
+
+func (self Attrs) index(key string) int {
+	if key == `` {
+		return -1
+	}
+	for ind, val := range self {
+		if val != (Attr{}) && val.Name() == key {
+			return ind
+		}
+	}
+	return -1
+}
+
 // Mostly for internal use.
 func (self Attrs) AppendTo(buf []byte) []byte {
 	for _, val := range self {
@@ -143,6 +232,13 @@ func (self Attrs) GoString() string {
 /*
 Represents an arbitrary HTML/XML attribute. Usually part of `Attrs{}`. An
 empty/zero attr (equal to `Attr{}`) is ignored during encoding.
+
+Attributes created via trusted types such as `Url` or `AttrName`, or via
+`Flag`, are marked as such by a signature appended to the raw name (`attr[0]`),
+which also covers the value. Such attributes don't compare equal to their
+plain counterparts. Copying such an attribute copies the mark, but using its
+raw name with a different value doesn't. `Attr.Name` returns the name without
+the signature.
 */
 type Attr [2]string
 
@@ -150,24 +246,40 @@ type Attr [2]string
 Attribute name. If the attr is not equal to `Attr{}`, the name is validated
 during encoding. Using an invalid name causes a panic.
 */
-func (self Attr) Name() string { return self[0] }
+func (self Attr) Name() string {
+	out, _, _ := markSplit(self[0])
+	return out
+}
 
 /*
 Attribute value. Automatically escaped and quoted when encoding the attr. For
 known HTML boolean attrs, listed in `Bool`, the value may be tweaked for better
-spec compliance, or the attr may be omitted entirely.
+spec compliance: "false" omits the attr, and any other value is dropped,
+leaving only the name. For attrs created via `Flag`, the value is "true" or
+"false", with the same effect regardless of `Bool`. For URL attrs, listed in
+`UrlAttrs`, unsafe values are replaced with `UrlInvalid`.
 */
 func (self Attr) Value() string { return self[1] }
 
-// Returns a modified version with `.Name` replaced with the given input.
+/*
+Returns a modified version with `.Name` replaced with the given input. The
+result is never trusted; see `Url`.
+*/
 func (self Attr) SetName(val string) Attr {
 	self[0] = val
 	return self
 }
 
-// Returns a modified version with `.Value` replaced with the given input.
+/*
+Returns a modified version with `.Value` replaced with the given input. The
+resulting value is never trusted; see `Url`.
+*/
 func (self Attr) Set(val string) Attr {
+	mark := self.mark()
 	self[1] = val
+	if mark != 0 {
+		self = self.withMark(mark &^ (markValue | markFlag))
+	}
 	return self
 }
 
@@ -186,26 +298,54 @@ func (self Attr) Add(val string) Attr {
 }
 
 // Mostly for internal use.
-func (self Attr) AppendTo(buf []byte) []byte {
+func (self Attr) AppendTo(buf []byte) []byte { return self.appendTo(buf, nil) }
+
+// Takes the state of the current render, which may be nil.
+func (self Attr) appendTo(buf []byte, st *state) []byte {
 	if self == (Attr{}) {
 		return buf
 	}
 
-	key, val := self.Name(), self.Value()
-	validAttr(key)
+	mark, key := self.marked()
+	val := self.Value()
+	if mark&markName == 0 {
+		validAttr(st, key)
+	}
 
-	if Bool.Has(key) {
-		// Dumb hack. Should revise.
-		if val == "false" {
+	if mark&markFlag != 0 || st.getDialect().IsBool(key) {
+		if val == `false` {
 			return buf
 		}
 		val = ""
+		if st.xml() {
+			val = key
+		}
+	} else if mark&markValue == 0 {
+		val = attrSafe(key, val)
 	}
 
 	buf = append(buf, ` `...)
 	buf = append(buf, key...)
+
+	if st.minify() {
+		if val == `` {
+			return buf
+		}
+		// Elements of foreign content may be self-closed after the attributes,
+		// see `Bui.End`, which would make "/" part of an unquoted value.
+		if isUnquotable(val) && !st.getDialect().foreign() {
+			buf = append(buf, `=`...)
+			_, _ = (*AttrWri)(&buf).WriteString(val)
+			return buf
+		}
+	}
+
 	buf = append(buf, `="`...)
-	_, _ = (*AttrWri)(&buf).WriteString(val)
+	if st.xml() {
+		_, _ = (*XmlWri)(&buf).WriteString(val)
+	} else {
+		_, _ = (*AttrWri)(&buf).WriteString(val)
+	}
 	buf = append(buf, `"`...)
 	return buf
 }
@@ -214,3 +354,133 @@ func (self Attr) AppendTo(buf []byte) []byte {
 func (self Attr) String() string {
 	return NonEscWri(self.AppendTo(nil)).String()
 }
+
+/*
+Tri-state value of a boolean attribute, such as "disabled": present, absent,
+or unspecified. Use `Flag.Attr` or `AV` to create attributes. Unlike string
+values, which are treated as boolean only for attributes listed in `Bool`,
+works for any attribute, including attributes of custom elements. For
+attributes whose values are the strings "true" and "false", such as
+"aria-expanded" or "draggable", use plain strings or `bool` instead.
+*/
+type Flag byte
+
+const (
+	// Unspecified. `Flag.Attr` returns `Attr{}`, which is ignored.
+	FlagUnset Flag = iota
+
+	// Present. Written as a bare name, or as `name="name"` in XML.
+	FlagOn
+
+	// Absent. The attribute is omitted from the output.
+	FlagOff
+)
+
+// Returns `FlagOn` for true and `FlagOff` for false.
+func FlagOf(val bool) Flag {
+	if val {
+		return FlagOn
+	}
+	return FlagOff
+}
+
+/*
+Returns an attribute with the given name and this value. For `FlagUnset`,
+returns `Attr{}`.
+*/
+func (self Flag) Attr(key string) Attr { return Attr{key, ``}.flag(self) }
+
+// Implement `fmt.Stringer` for debug purposes.
+func (self Flag) String() string {
+	switch self {
+	case FlagUnset:
+		return `unset`
+	case FlagOn:
+		return `on`
+	case FlagOff:
+		return `off`
+	default:
+		return `Flag(` + strconv.Itoa(int(self)) + `)`
+	}
+}
+
+func (self Attr) flag(val Flag) Attr {
+	if val != FlagOn && val != FlagOff {
+		return Attr{}
+	}
+	self = self.Set(strconv.FormatBool(val == FlagOn))
+	return self.withMark(self.mark() | markFlag)
+}
+
+/*
+Returns a version with the given typed value, as described in `AV`. Panics on
+unsupported types.
+*/
+func (self Attr) typed(val any) Attr {
+	switch val := val.(type) {
+	case bool:
+		return self.Set(strconv.FormatBool(val))
+	case int:
+		return self.Set(strconv.FormatInt(int64(val), 10))
+	case int8:
+		return self.Set(strconv.FormatInt(int64(val), 10))
+	case int16:
+		return self.Set(strconv.FormatInt(int64(val), 10))
+	case int32:
+		return self.Set(strconv.FormatInt(int64(val), 10))
+	case int64:
+		return self.Set(strconv.FormatInt(val, 10))
+	case uint:
+		return self.Set(strconv.FormatUint(uint64(val), 10))
+	case uint8:
+		return self.Set(strconv.FormatUint(uint64(val), 10))
+	case uint16:
+		return self.Set(strconv.FormatUint(uint64(val), 10))
+	case uint32:
+		return self.Set(strconv.FormatUint(uint64(val), 10))
+	case uint64:
+		return self.Set(strconv.FormatUint(val, 10))
+	case float32:
+		return self.Set(self.float(float64(val), 32))
+	case float64:
+		return self.Set(self.float(val, 64))
+	case time.Time:
+		return self.Set(val.Format(timeFormat))
+	case url.URL:
+		return self.Set(val.String())
+	case *url.URL:
+		if val == nil {
+			return Attr{}
+		}
+		return self.Set(val.String())
+	case fmt.Stringer:
+		return self.Set(val.String())
+	default:
+		panic(self.unsupported(val))
+	}
+}
+
+func (self Attr) unsupported(val any) AttrError {
+	return AttrError{self.Name(), val, fmt.Errorf(`unsupported value type %T for attribute %q`, val, self.Name())}
+}
+
+func (self Attr) encodeErr(val any, err error) AttrError {
+	return AttrError{self.Name(), val, fmt.Errorf(`failed to encode value of type %T for attribute %q: %w`, val, self.Name(), err)}
+}
+
+/*
+Formats a float as an HTML "valid floating-point number", without an exponent.
+Panics on NaN and infinities, which have no valid representation.
+*/
+func (self Attr) float(val float64, bits int) string {
+	if math.IsNaN(val) || math.IsInf(val, 0) {
+		panic(AttrError{self.Name(), val, fmt.Errorf(`can't encode non-finite number %v for attribute %q`, val, self.Name())})
+	}
+	return strconv.FormatFloat(val, 'f', -1, bits)
+}
+
+/*
+Format of `time.Time` attribute values: an HTML "valid global date and time
+string", with at most 3 fractional digits, as required by the spec.
+*/
+const timeFormat = `2006-01-02T15:04:05.999Z07:00`
@@ -2,6 +2,7 @@ package gax
 
 import (
 	"fmt"
+	"html/template"
 	r "reflect"
 )
 
@@ -44,15 +45,37 @@ Special rules for children:
 
 	* `nil` is ignored.
 	* `func()`, `func(*Bui)`, or `Ren.Render` is called for side effects.
+	* `func(*Bui) error` or `TryRen.TryRender` is called, and the error is
+	  reported via `Bui.Fail`.
 	* `[]any` is recursively walked.
 	* `[]Ren` is walked, calling `Ren.Render` on each val.
 	* `[]T` where `T` implements `Ren` is walked, calling `Ren.Render` on each val.
+	* `template.HTML` is written without escaping, like `Str`.
 	* Other values are stringified and escaped via `TextWri`.
 
 To write text without escaping, use `Str` for strings and `Bui` for byte
 slices.
+
+Raw text elements listed in `Raw`, such as `script` and `style`, have special
+rules. Their content can't be escaped, so text is written as-is, including
+text written by nested renderers. The resulting content must not contain the
+closing tag of the element (such as "</script"), or, in `script`, an unclosed
+"<!--" followed by "<script", which would break out of the element or change
+how it's parsed. Content which can't be embedded safely causes a panic.
+Trusted code may be provided as `Js` for `script` and `Css` for `style` (or
+their "html/template" counterparts); using them in the wrong element, or using
+other trusted types such as `Url`, also causes a panic.
+
+The same rules apply to content written between `Bui.Begin` and `Bui.End`,
+with or without settings such as `Ctx`.
 */
 func (self *Bui) E(tag string, attrs Attrs, children ...any) {
+	// Unlike the state attached by `Bui.Begin`, this is detached even on panic.
+	if len(children) > 0 && stateOf(self) == nil && isRaw(tag) {
+		withState(self, &state{}, func(bui *Bui) { bui.E(tag, attrs, children...) })
+		return
+	}
+
 	self.Begin(tag, attrs)
 	self.F(children...)
 	self.End(tag)
@@ -61,46 +84,105 @@ func (self *Bui) E(tag string, attrs Attrs, children ...any) {
 /*
 Mostly for internal use. Writes the beginning of an HTML/XML element, with
 optional attrs. Supports HTML special cases; see `Bui.Attrs`. Sanity-checks the
-tag. Using an invalid tag causes a panic.
+tag. Using an invalid tag causes a panic with `NameError`; see `Names`. When
+rendering with a `Ctx`, may add the attribute `nonce` or formatting
+whitespace; see `Ctx`. Starts the content of raw text elements, which is
+validated by `Bui.End`; see `Bui.E`. On a plain builder, this tracks open
+elements until the matching `Bui.End`; after a panic in between, the builder
+should be discarded, unless the panic was recovered by `Bui.TryE`.
 */
 func (self *Bui) Begin(tag string, attrs Attrs) {
-	validTag(tag)
+	st := stateOf(self)
+	validTag(st, tag)
+
+	if st == nil && isRaw(tag) {
+		st = &state{auto: true}
+		states.swap(self, st)
+	}
+
+	if st != nil {
+		st.begin(self, tag)
+	}
 
 	self.NonEscString(`<`)
 	self.NonEscString(tag)
 	self.Attrs(attrs...)
+
+	if st != nil {
+		if attr, ok := st.ctx.nonceAttr(tag, attrs); ok {
+			self.Attr(attr)
+		}
+	}
+
 	self.NonEscString(`>`)
+
+	if st != nil {
+		st.opened(self)
+	}
 }
 
 /*
 Mostly for internal use. Writes the end of an HTML/XML element. Supports HTML
-void elements, also known as self-closing tags: if `Void.Has(tag)`, this method
-is a nop. Sanity-checks the tag. Using an invalid tag causes a panic.
+void elements, also known as self-closing tags: if `Void.Has(tag)`, or if the
+current `Dialect` considers the element void, this method is a nop.
+Sanity-checks the tag. Using an invalid tag causes a panic. When rendering with
+`Ctx.Xml` or a self-closing `Dialect`, elements without content are
+self-closing instead.
 */
 func (self *Bui) End(tag string) {
-	validTag(tag)
+	st := stateOf(self)
+	validTag(st, tag)
+	dia, xml := st.getDialect(), st.xml()
+	closed := st != nil && st.end(self, tag)
 
-	if !Void.Has(tag) {
+	start := len(*self)
+	if !closed && (xml || !dia.IsVoid(tag)) {
 		self.NonEscString(`</`)
 		self.NonEscString(tag)
 		self.NonEscString(`>`)
 	}
+
+	if st != nil {
+		st.ended(self, tag, start, dia)
+		if st.stream != nil && st.raw == 0 {
+			st.stream.flushOver(self)
+		}
+		st.detach(self)
+	}
+}
+
+/*
+Explicit flush point for streaming. If this builder is being rendered by a
+`Stream`, writes the accumulated markup into the underlying writer, see
+`Stream.Flush`. Otherwise this is a nop.
+*/
+func (self *Bui) Flush() {
+	if st := stateOf(self); st != nil && st.stream != nil && st.raw == 0 {
+		st.stream.drain(self)
+		_ = st.stream.flushWri(self)
+	}
 }
 
 /*
 Mostly for internal use. Writes HTML/XML attributes. Supports HTML special
 cases; see `Bui.Attr`.
 */
-func (self *Bui) Attrs(vals ...Attr) { *self = Bui(Attrs(vals).AppendTo(*self)) }
+func (self *Bui) Attrs(vals ...Attr) {
+	st := stateOf(self)
+	for _, val := range vals {
+		*self = Bui(val.appendTo(*self, st))
+	}
+}
 
 /*
 Mostly for internal use. Writes an HTML/XML attribute, preceded with a space.
-Supports HTML bool attrs: if `Bool.Has(key)`, the attribute value may be
-adjusted for spec compliance. Automatically escapes the attribute value.
+Supports HTML bool attrs: if `Bool.Has(key)`, or if the current `Dialect`
+considers the attribute boolean, the attribute value may be adjusted for spec
+compliance. Automatically escapes the attribute value.
 
 Sanity-checks the attribute name. Using an invalid name causes a panic.
 */
-func (self *Bui) Attr(val Attr) { *self = Bui(val.AppendTo(*self)) }
+func (self *Bui) Attr(val Attr) { *self = Bui(val.appendTo(*self, stateOf(self))) }
 
 // Writes multiple children via `Bui.Child`. Like the "tail part" of `Bui.E`.
 // Counterpart to the function `F`.
@@ -134,7 +216,22 @@ Writes text content, escaping if necessary. For writing `string`, see
 `Bui.EscString`.
 */
 func (self *Bui) EscBytes(val []byte) {
-	_, _ = (*TextWri)(self).Write(val)
+	st := stateOf(self)
+	if st.rawTag() != `` {
+		self.NonEscBytes(val)
+		return
+	}
+
+	start := len(*self)
+	if st.xml() {
+		_, _ = (*XmlWri)(self).Write(val)
+	} else {
+		_, _ = (*TextWri)(self).Write(val)
+	}
+
+	if st != nil {
+		st.text(self, start)
+	}
 }
 
 /*
@@ -142,7 +239,22 @@ Writes text content, escaping if necessary. For writing `[]byte`, see
 `Bui.EscBytes`.
 */
 func (self *Bui) EscString(val string) {
-	_, _ = (*TextWri)(self).WriteString(val)
+	st := stateOf(self)
+	if st.rawTag() != `` {
+		self.NonEscString(val)
+		return
+	}
+
+	start := len(*self)
+	if st.xml() {
+		_, _ = (*XmlWri)(self).WriteString(val)
+	} else {
+		_, _ = (*TextWri)(self).WriteString(val)
+	}
+
+	if st != nil {
+		st.text(self, start)
+	}
 }
 
 // Shorter alias for `Bui.EscString`.
@@ -164,6 +276,13 @@ func (self *Bui) Child(val any) {
 	case []byte:
 		self.EscBytes(val)
 
+	case template.HTML:
+		if tag := stateOf(self).rawTag(); tag != `` {
+			self.rawChild(tag, val)
+		} else {
+			self.NonEscString(string(val))
+		}
+
 	case func():
 		if val != nil {
 			val()
@@ -179,6 +298,16 @@ func (self *Bui) Child(val any) {
 			self.Child(val())
 		}
 
+	case func(*Bui) error:
+		if val != nil {
+			self.Fail(val(self))
+		}
+
+	case TryRen:
+		if val != nil {
+			self.tryRender(val)
+		}
+
 	case Ren:
 		if val != nil {
 			val.Render(self)
@@ -220,8 +349,36 @@ func (self *Bui) unknown(src any) {
 
 	switch typ.Kind() {
 	case r.Invalid, r.Func:
-		panic(fmt.Errorf(`[gax] can't render %T`, src))
+		panic(ChildError{src, stateOf(self).path()})
 	}
 
-	fmt.Fprint((*TextWri)(self), src)
+	st := stateOf(self)
+	if tag := st.rawTag(); tag != `` {
+		self.rawChild(tag, src)
+	} else if st.xml() {
+		fmt.Fprint((*XmlWri)(self), src)
+	} else {
+		fmt.Fprint((*TextWri)(self), src)
+	}
+}
+
+/*
+Writes a child of a raw text element which isn't a renderer, such as text or
+a trusted type. See `Bui.E` for the rules.
+*/
+func (self *Bui) rawChild(tag string, val any) {
+	switch val := val.(type) {
+	case Js:
+		self.rawTrusted(tag, val, string(val))
+	case template.JS:
+		self.rawTrusted(tag, val, string(val))
+	case Css:
+		self.rawTrusted(tag, val, string(val))
+	case template.CSS:
+		self.rawTrusted(tag, val, string(val))
+	case Url, Srcset, template.URL, template.Srcset, template.HTML:
+		panic(RawError{Tag: tag, Val: val, Path: stateOf(self).path()})
+	default:
+		fmt.Fprint((*NonEscWri)(self), val)
+	}
 }
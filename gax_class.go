@@ -0,0 +1,86 @@
+package gax
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/*
+Builder for the "class" attribute, similar to the JS idiom "clsx". Elements may
+be:
+
+	* `string`: one or more space-separated class names.
+	* `map[string]bool`: class names included when the value is true. Map
+	  entries have no order, so they're added in sorted order.
+	* `[]string`, `[]any`, `Class`: nested lists, walked recursively.
+	* nil: ignored.
+
+Other types cause a panic. Class names are deduplicated, preserving the order
+of their first occurrence, and empty entries are ignored. Example:
+
+	E(`button`, A(Class{`btn`, `btn-lg`, map[string]bool{`active`: active}}.Attr()))
+
+	AP(`class`, `one`).Add(`class`, Class{`two`, extra}.String())
+
+Also implements `fmt.Stringer`, and may be passed as a value to `AV`.
+*/
+type Class []any
+
+/*
+Returns the "class" attribute with the resulting class names. If there are no
+class names, returns `Attr{}`, which is ignored during encoding.
+*/
+func (self Class) Attr() Attr {
+	val := self.String()
+	if val == `` {
+		return Attr{}
+	}
+	return Attr{`class`, val}
+}
+
+// Returns the resulting class names, space-separated.
+func (self Class) String() string {
+	return strings.Join(self.appendTo(nil), ` `)
+}
+
+func (self Class) appendTo(buf []string) []string {
+	for _, val := range self {
+		buf = appendClass(buf, val)
+	}
+	return buf
+}
+
+func appendClass(buf []string, src any) []string {
+	switch src := src.(type) {
+	case nil:
+	case string:
+		for _, val := range strings.Fields(src) {
+			if !hasString(buf, val) {
+				buf = append(buf, val)
+			}
+		}
+	case map[string]bool:
+		keys := make([]string, 0, len(src))
+		for key, ok := range src {
+			if ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			buf = appendClass(buf, key)
+		}
+	case []string:
+		for _, val := range src {
+			buf = appendClass(buf, val)
+		}
+	case []any:
+		buf = Class(src).appendTo(buf)
+	case Class:
+		buf = src.appendTo(buf)
+	default:
+		panic(AttrError{`class`, src, fmt.Errorf(`unsupported class type %T`, src)})
+	}
+	return buf
+}
@@ -0,0 +1,38 @@
+package gax
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestClass(t *testing.T) {
+	test := func(src Class, exp string) {
+		t.Helper()
+		eq(t, src.String(), exp)
+	}
+
+	test(nil, ``)
+	test(Class{nil, ``, ` `, []string{}, map[string]bool{}}, ``)
+	test(Class{`one`}, `one`)
+	test(Class{` one  two `, "three\tone"}, `one two three`)
+	test(Class{`one`, map[string]bool{`two`: true, `three`: false, `four`: true}}, `one four two`)
+	test(Class{`one`, []string{`two`, `one`}, []any{`three`, Class{`four`, nil}}}, `one two three four`)
+	test(Class{map[string]bool{`one two`: true}, `two`}, `one two`)
+}
+
+func TestClass_Attr(t *testing.T) {
+	eq(t, Class{}.Attr(), Attr{})
+	eq(t, Class{``, map[string]bool{`one`: false}}.Attr(), Attr{})
+	eq(t, Class{`one`, `two`}.Attr(), Attr{`class`, `one two`})
+
+	eqs(t, E(`div`, A(Class{`one`, map[string]bool{`two`: true}}.Attr())), `<div class="one two"></div>`)
+	eqs(t, E(`div`, A(Class{nil}.Attr())), `<div></div>`)
+	eqs(t, E(`div`, AV(`class`, Class{`one`})), `<div class="one"></div>`)
+	eq(t, AP(`class`, `one`).Add(`class`, Class{`two`}.String()), AP(`class`, `one two`))
+	eq(t, AP(`class`, `one`).Merge(A(Class{`two`, `one`}.Attr())), AP(`class`, `one two`))
+}
+
+func TestClass_panic(t *testing.T) {
+	defer func() { eq(t, fmt.Sprint(recover()), `[gax] unsupported class type int`) }()
+	_ = Class{`one`, 10}.String()
+}
@@ -0,0 +1,72 @@
+package gax
+
+import "context"
+
+/*
+Returns the context of the current render, for reading request-scoped values
+such as the locale or the current user, without passing them through every
+intermediate renderer. This is `Ctx.Context`, overridden for subtrees via
+`Bui.With` or `WithValue`. Returns `context.Background()` when there's no
+context. Example:
+
+	func Greeting(bui *Bui) {
+		bui.E(`p`, nil, translate(bui.Value(localeKey{}), `hello`))
+	}
+*/
+func (self *Bui) Context() context.Context {
+	if st := stateOf(self); st != nil {
+		if st.context != nil {
+			return st.context
+		}
+		if st.ctx != nil && st.ctx.Context != nil {
+			return st.ctx.Context
+		}
+	}
+	return context.Background()
+}
+
+// Shortcut for `bui.Context().Value(key)`.
+func (self *Bui) Value(key any) any { return self.Context().Value(key) }
+
+/*
+Renders the given children into this builder, as if by calling `Bui.F`, with
+the given context returned by `Bui.Context` for the duration of the call.
+Should be derived from `Bui.Context` to preserve outer values. Nil restores
+`Ctx.Context`. Example:
+
+	bui.With(context.WithValue(bui.Context(), localeKey{}, `de`), Page(dat))
+*/
+func (self *Bui) With(ctx context.Context, vals ...any) {
+	st := stateOf(self)
+	if st == nil {
+		withState(self, &state{context: ctx}, func(bui *Bui) { bui.F(vals...) })
+		return
+	}
+
+	prev := st.context
+	st.context = ctx
+	defer func() { st.context = prev }()
+
+	self.F(vals...)
+}
+
+/*
+Returns a `Scope` which renders the given children with the given value added
+to `Bui.Context`, as if by `context.WithValue`. Expression-style equivalent of
+`Bui.With`. Example:
+
+	E(`div`, nil, WithValue(localeKey{}, `de`, Sidebar{}, Footer{}))
+*/
+func WithValue(key, val any, vals ...any) Scope { return Scope{key, val, vals} }
+
+// Implementation of `WithValue`. See `WithValue` for details.
+type Scope struct {
+	Key   any
+	Val   any
+	Child []any
+}
+
+// Implement `Ren`. See `WithValue` for details.
+func (self Scope) Render(bui *Bui) {
+	bui.With(context.WithValue(bui.Context(), self.Key, self.Val), self.Child...)
+}
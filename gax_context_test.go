@@ -0,0 +1,99 @@
+package gax
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type ctxKey struct{}
+
+func ctxRen(bui *Bui) { bui.T(fmt.Sprint(bui.Value(ctxKey{}))) }
+
+func TestBui_Context(t *testing.T) {
+	var bui Bui
+	eq(t, bui.Context(), context.Background())
+	eq(t, bui.Value(ctxKey{}), nil)
+
+	eqs(t, F(ctxRen), `&lt;nil&gt;`)
+
+	ctx := Ctx{Context: context.WithValue(context.Background(), ctxKey{}, `one`)}
+	eqs(t, ctx.F(E(`p`, nil, ctxRen)), `<p>one</p>`)
+	eqs(t, (&Ctx{}).F(ctxRen), `&lt;nil&gt;`)
+}
+
+func TestBui_With(t *testing.T) {
+	ctx := Ctx{Context: context.WithValue(context.Background(), ctxKey{}, `one`)}
+
+	eqs(
+		t,
+		ctx.F(
+			ctxRen,
+			func(bui *Bui) {
+				bui.With(context.WithValue(bui.Context(), ctxKey{}, `two`), E(`p`, nil, ctxRen))
+				bui.With(nil, E(`p`, nil, ctxRen))
+			},
+			ctxRen,
+		),
+		`one<p>two</p><p>one</p>one`,
+	)
+
+	var bui Bui
+	bui.With(context.WithValue(context.Background(), ctxKey{}, `one`), ctxRen)
+	bui.C(ctxRen)
+	eqs(t, bui, `one&lt;nil&gt;`)
+	eq(t, stateOf(&bui), nil)
+}
+
+func TestBui_With_closure(t *testing.T) {
+	var bui Bui
+	E := bui.E
+
+	bui.With(context.WithValue(context.Background(), ctxKey{}, `one`), func() {
+		E(`p`, nil, func() { bui.C(ctxRen) })
+	}, `kept`)
+
+	eqs(t, bui, `<p>one</p>kept`)
+	eq(t, stateOf(&bui), nil)
+}
+
+func TestWithValue(t *testing.T) {
+	eqs(
+		t,
+		F(
+			E(`div`, nil,
+				WithValue(ctxKey{}, `one`,
+					E(`p`, nil, ctxRen),
+					WithValue(ctxKey{}, `two`, ctxRen),
+					ctxRen,
+				),
+			),
+			ctxRen,
+		),
+		`<div><p>one</p>twoone</div>&lt;nil&gt;`,
+	)
+
+	ctx := Ctx{Nonce: `abc`, Context: context.WithValue(context.Background(), ctxKey{}, `one`)}
+	eqs(
+		t,
+		ctx.F(WithValue(ctxKey{}, `two`, func(bui *Bui) {
+			(&Ctx{Nonce: `def`}).Into(bui, E(`script`, nil), ctxRen)
+			(&Ctx{Context: context.Background()}).Into(bui, ctxRen)
+		})),
+		`<script nonce="def"></script>two&lt;nil&gt;`,
+	)
+}
+
+func TestWithValue_Defer(t *testing.T) {
+	var buf strings.Builder
+	out := Stream{Wri: &buf}
+
+	out.C(WithValue(ctxKey{}, `one`, Defer(nil, E(`p`, nil, ctxRen))))
+	out.C(Defer(nil, E(`p`, nil, ctxRen)))
+	must(out.Close())
+
+	str := buf.String()
+	eq(t, strings.Contains(str, `<template id="gax-r1"><p>one</p></template>`), true)
+	eq(t, strings.Contains(str, `<template id="gax-r2"><p>&lt;nil&gt;</p></template>`), true)
+}
@@ -0,0 +1,299 @@
+package gax
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+/*
+Short for "context" or "render context". Optional settings for a specific
+render, which also collects information about the rendered markup. Settings
+are picked up by all nested renderers, including `Ren` and `func(*Bui)`,
+without having to pass them around manually. Settings belong to the builder
+being rendered: markup built separately, such as via the function `F` inside
+a renderer, is rendered without them. Within renderers, prefer `Bui.E`,
+`Bui.F` or the lazy `E`.
+
+To render with a context, use `Ctx.F` or `Ctx.Into`, or set `Stream.Ctx`. A
+context should be used for one render at a time, and may be used for one
+document across several calls.
+
+Content Security Policy: when `.Nonce` is set, every `script` and `style`
+element, as well as `link` elements preloading scripts or styles, automatically
+get the attribute `nonce`, unless it's already present. Additionally, the
+SHA-256 hashes of inline `script` and `style` content are collected. Use
+`Ctx.Csp` to generate the matching header value. For generating nonces, see
+`Nonce`.
+
+Pretty printing: when `.Indent` is set, such as to "\t" or "  ", elements
+listed in `Block` are placed on their own lines and indented by nesting depth,
+which is convenient for debugging. Formatting applies only where whitespace is
+insignificant: between blocks whose ancestors are all blocks. The content of
+inline elements and elements listed in `Verbatim`, such as `pre`, `textarea`,
+`script` and `style`, is left as-is, and so is text. The output renders the
+same as the compact form.
+
+Minification: when `.Minify` is set, the output is made smaller without
+affecting the parsed document: optional end tags such as "</li>", "</p>",
+"</td>" or "</html>" are omitted where the HTML specification allows it,
+attribute values are written without quotes when possible (outside of foreign
+content such as SVG, where elements may be self-closing), empty attribute
+values and boolean attributes are written as bare names, and whitespace-only
+text between elements listed in `Block` is dropped, except inside elements
+listed in `Verbatim`, such as `pre`. Markup written via `Str` and other
+pre-escaped types is left as-is. The end tag of "html" is omitted only at the
+end of `Ctx.Into` or `Stream.Close`; avoid appending more markup afterwards.
+Overrides `.Indent`.
+
+XML: when `.Xml` is set, the output is serialized as XML, suitable for XHTML,
+SVG documents, feeds and other XML formats. Elements without content are
+self-closing, such as "<br/>" or "<div/>", and `Void` is not consulted.
+Boolean attributes listed in `Bool` are written as "checked=\"checked\"".
+Elements listed in `Raw` are not special, and their content is escaped like
+any other text. Text and attribute values are escaped via `XmlWri`, which
+escapes apostrophes and avoids HTML-only entities. To prepend an XML
+declaration, use `XmlDecl`. Overrides `.Minify`.
+
+Dialects: `.Dialect` selects the tables of void elements, boolean attributes
+and raw text elements, and other rules, used for this render. See `Dialect`.
+When nil, the global tables `Void`, `Bool` and `Raw` are used.
+
+Values: `.Context` provides request-scoped values, such as the locale or the
+current user, to nested renderers, which read them via `Bui.Context` or
+`Bui.Value`. Subtrees may override values via `Bui.With` or `WithValue`. Also
+useful for cancellation of slow renderers.
+*/
+type Ctx struct {
+	Nonce   string
+	Indent  string
+	Minify  bool
+	Xml     bool
+	Dialect *Dialect
+	Context context.Context
+	scripts []string
+	styles  []string
+}
+
+/*
+Similar to the function `F`, but renders with this context. Shortcut for
+`Ctx.Into`.
+*/
+func (self *Ctx) F(vals ...any) (bui Bui) {
+	self.Into(&bui, vals...)
+	return
+}
+
+/*
+Renders the given children into the given builder, as if by calling
+`Bui.F`, with this context attached to the builder for the duration of the
+call.
+*/
+func (self *Ctx) Into(bui *Bui, vals ...any) { self.into(bui, nil, vals) }
+
+/*
+Implementation of `Ctx.Into`. When `errs` is non-nil, errors reported via
+`Bui.Fail` are collected there, see `Ctx.TryF`.
+*/
+func (self *Ctx) into(bui *Bui, errs *[]error, vals []any) {
+	prev := stateOf(bui)
+	next := state{ctx: self, dialect: self.Dialect}
+	if prev != nil {
+		next = *prev
+		next.ctx = self
+		next.tails = nil
+		if self.Dialect != nil {
+			next.dialect = self.Dialect
+		}
+		if self.Context != nil {
+			next.context = nil
+		}
+	}
+	if errs != nil {
+		next.errs = errs
+	}
+
+	withState(bui, &next, func(bui *Bui) {
+		bui.F(vals...)
+		if prev == nil {
+			next.finish(bui)
+		}
+	})
+}
+
+/*
+Returns a value for the "Content-Security-Policy" header which allows scripts
+and styles with `.Nonce`, and inline scripts and styles rendered so far with
+this context, by their hashes. Usage:
+
+	ctx := gax.Ctx{Nonce: gax.Nonce()}
+	body := ctx.F(Page(dat))
+	rew.Header().Set(`Content-Security-Policy`, ctx.Csp())
+
+When streaming, headers must be sent before the content is known, so hashes
+are unavailable and only the nonce is useful.
+*/
+func (self *Ctx) Csp() string {
+	var buf []string
+	if val := self.cspSources(self.scripts); val != `` {
+		buf = append(buf, `script-src`+val)
+	}
+	if val := self.cspSources(self.styles); val != `` {
+		buf = append(buf, `style-src`+val)
+	}
+	return strings.Join(buf, `; `)
+}
+
+func (self *Ctx) cspSources(hashes []string) string {
+	var buf strings.Builder
+	if self.Nonce != `` {
+		buf.WriteString(` 'nonce-`)
+		buf.WriteString(self.Nonce)
+		buf.WriteString(`'`)
+	}
+	for _, val := range hashes {
+		buf.WriteString(` '`)
+		buf.WriteString(val)
+		buf.WriteString(`'`)
+	}
+	return buf.String()
+}
+
+func (self *Ctx) nonceAttr(tag string, attrs Attrs) (Attr, bool) {
+	if self == nil || self.Nonce == `` || !isNonceTag(tag, attrs) || attrsHas(attrs, `nonce`) {
+		return Attr{}, false
+	}
+	return Attr{`nonce`, self.Nonce}, true
+}
+
+func (self *Ctx) hashRaw(tag string, val []byte) {
+	if self == nil || len(val) == 0 {
+		return
+	}
+
+	switch strings.ToLower(tag) {
+	case `script`:
+		self.scripts = appendUniq(self.scripts, cspHash(val))
+	case `style`:
+		self.styles = appendUniq(self.styles, cspHash(val))
+	}
+}
+
+// Used for merging data collected by deferred renders.
+func (self *Ctx) merge(src *Ctx) {
+	if self == nil || src == nil {
+		return
+	}
+	for _, val := range src.scripts {
+		self.scripts = appendUniq(self.scripts, val)
+	}
+	for _, val := range src.styles {
+		self.styles = appendUniq(self.styles, val)
+	}
+}
+
+// Used for deferred renders, which run concurrently.
+func (self *Ctx) fork() *Ctx {
+	if self == nil {
+		return nil
+	}
+	return &Ctx{
+		Nonce:   self.Nonce,
+		Indent:  self.Indent,
+		Minify:  self.Minify,
+		Xml:     self.Xml,
+		Dialect: self.Dialect,
+		Context: self.Context,
+	}
+}
+
+func (self *Ctx) indent() string {
+	if self == nil || self.Minify {
+		return ``
+	}
+	return self.Indent
+}
+
+func (self *Ctx) minify() bool { return self != nil && self.Minify && !self.Xml }
+
+func (self *Ctx) xml() bool { return self != nil && self.Xml }
+
+func (self *Ctx) dialect() *Dialect {
+	if self == nil {
+		return nil
+	}
+	return self.Dialect
+}
+
+func ctxOf(bui *Bui) *Ctx {
+	if st := stateOf(bui); st != nil {
+		return st.ctx
+	}
+	return nil
+}
+
+/*
+Generates a random nonce suitable for `Ctx.Nonce`: 128 bits from a
+cryptographically secure source, base64-encoded.
+*/
+func Nonce() string {
+	var buf [16]byte
+	_, err := rand.Read(buf[:])
+	if err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(buf[:])
+}
+
+func isNonceTag(tag string, attrs Attrs) bool {
+	switch strings.ToLower(tag) {
+	case `script`, `style`:
+		return true
+
+	case `link`:
+		switch strings.ToLower(attrsGet(attrs, `rel`)) {
+		case `modulepreload`:
+			return true
+		case `preload`:
+			switch strings.ToLower(attrsGet(attrs, `as`)) {
+			case `script`, `style`:
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func cspHash(val []byte) string {
+	sum := sha256.Sum256(val)
+	return `sha256-` + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func attrsHas(attrs Attrs, key string) bool {
+	for _, val := range attrs {
+		if val != (Attr{}) && strings.EqualFold(val.Name(), key) {
+			return true
+		}
+	}
+	return false
+}
+
+func attrsGet(attrs Attrs, key string) string {
+	for _, val := range attrs {
+		if val != (Attr{}) && strings.EqualFold(val.Name(), key) {
+			return val.Value()
+		}
+	}
+	return ``
+}
+
+func appendUniq(vals []string, val string) []string {
+	for _, prev := range vals {
+		if prev == val {
+			return vals
+		}
+	}
+	return append(vals, val)
+}
@@ -0,0 +1,208 @@
+package gax
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestCtx_Nonce(t *testing.T) {
+	ctx := Ctx{Nonce: `abc`}
+
+	eqs(
+		t,
+		ctx.F(
+			E(`script`, AP(`src`, `/one.js`)),
+			func(b *Bui) { b.E(`style`, nil) },
+			E(`script`, AP(`nonce`, `def`)),
+			E(`link`, AP(`rel`, `preload`, `as`, `script`, `href`, `/two.js`)),
+			E(`link`, AP(`rel`, `preload`, `as`, `image`, `href`, `/three.png`)),
+			E(`link`, AP(`rel`, `modulepreload`, `href`, `/four.js`)),
+			E(`link`, AP(`rel`, `stylesheet`, `href`, `/five.css`)),
+			E(`div`, nil),
+		),
+		`<script src="/one.js" nonce="abc"></script><style nonce="abc"></style><script nonce="def"></script><link rel="preload" as="script" href="/two.js" nonce="abc"><link rel="preload" as="image" href="/three.png"><link rel="modulepreload" href="/four.js" nonce="abc"><link rel="stylesheet" href="/five.css"><div></div>`,
+	)
+
+	eqs(t, F(E(`script`, nil)), `<script></script>`)
+	eqs(t, (&Ctx{}).F(E(`script`, nil)), `<script></script>`)
+}
+
+func TestCtx_Into(t *testing.T) {
+	ctx := Ctx{Nonce: `abc`}
+	bui := Bui(Doctype)
+
+	ctx.Into(&bui, E(`script`, nil))
+	bui.E(`script`, nil)
+
+	eqs(t, bui, `<!doctype html><script nonce="abc"></script><script></script>`)
+	eq(t, stateOf(&bui), nil)
+}
+
+func TestCtx_Into_builder(t *testing.T) {
+	ctx := Ctx{Nonce: `abc`}
+	var outer, inner *Bui
+
+	eqs(
+		t,
+		ctx.F(func(bui *Bui) {
+			outer = bui
+			ctx.Into(bui, func(bui *Bui) { inner = bui })
+
+			var other Bui
+			eq(t, stateOf(&other), nil)
+			bui.Child(F(E(`script`, nil)))
+			bui.E(`script`, nil)
+		}),
+		`<script></script><script nonce="abc"></script>`,
+	)
+
+	eq(t, outer == inner, true)
+	eq(t, stateOf(outer), nil)
+}
+
+func TestCtx_Into_closure(t *testing.T) {
+	var bui Bui
+	E := bui.E
+
+	(&Ctx{Nonce: `abc`}).Into(&bui, func() { E(`script`, nil) })
+	E(`p`, nil, func() { E(`script`, nil) })
+
+	eqs(t, bui, `<script nonce="abc"></script><p><script></script></p>`)
+	eq(t, stateOf(&bui), nil)
+}
+
+func TestCtx_Csp(t *testing.T) {
+	eq(t, (&Ctx{}).Csp(), ``)
+	eq(t, (&Ctx{Nonce: `abc`}).Csp(), `script-src 'nonce-abc'; style-src 'nonce-abc'`)
+
+	var ctx Ctx
+	ctx.F(
+		E(`script`, nil, `one`),
+		E(`script`, nil, `one`),
+		E(`script`, AP(`src`, `/two.js`)),
+		E(`style`, nil, `three`),
+	)
+
+	eq(
+		t,
+		ctx.Csp(),
+		`script-src 'sha256-dpLDrTVAu4A8Ags67mbNiIcSMjTqDG5xQ8Ct1z/0Me0='; style-src 'sha256-i1udsME9skJWyCmqNkqpDG0uujGLkjKkq5MTuVTTVV8='`,
+	)
+
+	ctx.Nonce = `abc`
+	eq(t, strings.HasPrefix(ctx.Csp(), `script-src 'nonce-abc' 'sha256-`), true)
+}
+
+func TestCtx_Stream(t *testing.T) {
+	var buf strings.Builder
+	out := Stream{Wri: &buf, Ctx: &Ctx{Nonce: `abc`}}
+
+	out.E(`div`, nil, Defer(nil, E(`script`, nil, `one`)))
+	must(out.Close())
+
+	eq(t, buf.String(), `<div><template id="gax-d1"></template><!--/gax-d1--></div><script nonce="abc">`+deferScript+`</script><template id="gax-r1"><script nonce="abc">one</script></template><script nonce="abc">gaxSwap(1)</script>`)
+	eq(t, out.Ctx.scripts, []string{
+		cspHash([]byte(`one`)),
+		cspHash([]byte(deferScript)),
+		cspHash([]byte(`gaxSwap(1)`)),
+	})
+}
+
+func TestNonce(t *testing.T) {
+	one, two := Nonce(), Nonce()
+	eq(t, one == two, false)
+
+	val, err := base64.StdEncoding.DecodeString(one)
+	must(err)
+	eq(t, len(val), 16)
+}
+
+func TestCtx_Indent(t *testing.T) {
+	ctx := Ctx{Indent: `  `}
+
+	eqs(
+		t,
+		ctx.F(
+			Str(Doctype),
+			E(`html`, nil,
+				E(`head`, nil,
+					E(`meta`, AP(`charset`, `utf-8`)),
+					E(`title`, nil, `one`),
+					E(`script`, nil, "if (a) {\n}"),
+				),
+				E(`body`, nil,
+					E(`div`, nil,
+						E(`p`, nil, `two `, E(`b`, nil, `three`)),
+						E(`span`, nil, E(`div`, nil, `four`)),
+						E(`pre`, nil, E(`div`, nil, "five\n")),
+						E(`ul`, nil, E(`li`, nil, `six`), E(`li`, nil, E(`p`, nil, `seven`))),
+					),
+					E(`textarea`, nil, "eight\n"),
+				),
+			),
+		),
+		`<!doctype html>
+<html>
+  <head>
+    <meta charset="utf-8">
+    <title>one</title>
+    <script>if (a) {
+}</script>
+  </head>
+  <body>
+    <div>
+      <p>two <b>three</b></p><span><div>four</div></span>
+      <pre><div>five
+</div></pre>
+      <ul>
+        <li>six</li>
+        <li>
+          <p>seven</p>
+        </li>
+      </ul>
+    </div><textarea>eight
+</textarea>
+  </body>
+</html>`,
+	)
+
+	eqs(t, ctx.F(E(`p`, nil, `one`), E(`p`, nil, `two`)), "<p>one</p>\n<p>two</p>")
+	eqs(t, ctx.F(E(`span`, nil, E(`p`, nil, `one`))), `<span><p>one</p></span>`)
+}
+
+func TestCtx_Indent_Stream(t *testing.T) {
+	var buf strings.Builder
+	out := Stream{Wri: &buf, Ctx: &Ctx{Indent: "\t"}}
+
+	out.E(`div`, nil, E(`p`, nil, `one`))
+	eq(t, out.Flush(), nil)
+	out.E(`div`, nil, E(`p`, nil, `two`))
+	eq(t, out.Close(), nil)
+
+	eq(t, buf.String(), "<div>\n\t<p>one</p>\n</div>\n<div>\n\t<p>two</p>\n</div>")
+}
+
+func TestCtx_Xml(t *testing.T) {
+	ctx := Ctx{Xml: true}
+
+	eqs(
+		t,
+		ctx.F(
+			Str(XmlDecl),
+			E(`html`, AP(`xmlns`, `http://www.w3.org/1999/xhtml`),
+				E(`br`, nil),
+				E(`div`, nil),
+				E(`p`, nil, ``),
+				E(`input`, AP(`checked`, ``, `disabled`, `false`, `title`, `one's "two"`)),
+				E(`p`, nil, "one's <two> & three\u00a0four"),
+				E(`script`, nil, `if (a < b && c) {}`),
+				E(`link`, AP(`href`, `/one`), E(`meta`, nil)),
+			),
+		),
+		`<?xml version="1.0" encoding="UTF-8"?><html xmlns="http://www.w3.org/1999/xhtml"><br/><div/><p/><input checked="checked" title="one&#39;s &quot;two&quot;"/><p>one&#39;s &lt;two&gt; &amp; three`+"\u00a0"+`four</p><script>if (a &lt; b &amp;&amp; c) {}</script><link href="/one"><meta/></link></html>`,
+	)
+
+	eqs(t, (&Ctx{Xml: true, Minify: true}).F(E(`ul`, nil, E(`li`, AP(`class`, `one`), `two`), ` `)), `<ul><li class="one">two</li> </ul>`)
+	eqs(t, (&Ctx{Xml: true, Indent: `  `}).F(E(`div`, nil, E(`p`, nil), E(`p`, nil, `one`))), "<div>\n  <p/>\n  <p>one</p>\n</div>")
+}
@@ -0,0 +1,143 @@
+package gax
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	r "reflect"
+	"strings"
+	"time"
+)
+
+/*
+Returns a custom data attribute "data-<key>" with the given value, for passing
+data to scripts. The key must be a valid suffix: non-empty, lowercase, and
+XML-compatible, as required by the spec; otherwise this panics with
+`NameError`. The value is encoded as follows:
+
+	* nil: returns `Attr{}`, which is ignored during encoding.
+	* Scalars, `Flag`, `time.Time`, `url.URL` and trusted types: as in `AV`.
+	* Types implementing `json.Marshaler`: JSON.
+	* Other types implementing `fmt.Stringer`: the result of `.String`.
+	* Structs, maps, slices, arrays and pointers: JSON.
+
+Strings with an underlying string type, and similar for other scalars, are
+formatted like the base types. The result is escaped like any other attribute
+value. Example:
+
+	E(`div`, AP(`class`, `widget`).A(Data(`config`, conf), Data(`id`, 10)))
+*/
+func Data(key string, val any) Attr {
+	out := Attr{`data-` + key, ``}
+	if !isDataSuffix(key) {
+		panic(NameError{Attr: out.Name(), Names: NamesHtml})
+	}
+
+	switch val := val.(type) {
+	case nil:
+		return Attr{}
+	case time.Time, url.URL, *url.URL, Flag:
+		return attrOf(out.Name(), val)
+	case json.Marshaler:
+		return out.json(val)
+	case fmt.Stringer:
+		return out.Set(val.String())
+	}
+
+	if out, ok := out.scalar(val); ok {
+		return out
+	}
+	return out.json(val)
+}
+
+/*
+Returns an accessibility attribute "aria-<key>" with the given value. The key
+must consist of lowercase ASCII letters, such as "expanded" or "labelledby";
+otherwise this panics with `NameError`. Booleans are written as "true" or
+"false", which ARIA requires, and are never omitted. `[]string` is written as
+a space-separated list, for attributes such as "aria-describedby" which
+reference multiple IDs. Nil returns `Attr{}`, which is ignored during
+encoding. Other values are formatted as in `AV`, and composite values cause a
+panic.
+*/
+func Aria(key string, val any) Attr {
+	out := Attr{`aria-` + key, ``}
+	if !isAriaSuffix(key) {
+		panic(NameError{Attr: out.Name(), Names: NamesHtml})
+	}
+
+	switch val := val.(type) {
+	case nil:
+		return Attr{}
+	case []string:
+		return out.Set(strings.Join(val, ` `))
+	case fmt.Stringer:
+		return out.Set(val.String())
+	}
+
+	if out, ok := out.scalar(val); ok {
+		return out
+	}
+	panic(out.unsupported(val))
+}
+
+/*
+Returns a version with the given value if it's a scalar, including types whose
+underlying type is a scalar, and a boolean indicating success.
+*/
+func (self Attr) scalar(val any) (Attr, bool) {
+	switch val.(type) {
+	case string, bool,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64,
+		Url, Srcset, Css, Js, AttrName, Flag:
+		return attrOf(self.Name(), val), true
+	}
+
+	rval := r.ValueOf(val)
+	switch rval.Kind() {
+	case r.String:
+		return self.Set(rval.String()), true
+	case r.Bool:
+		return self.typed(rval.Bool()), true
+	case r.Int, r.Int8, r.Int16, r.Int32, r.Int64:
+		return self.typed(rval.Int()), true
+	case r.Uint, r.Uint8, r.Uint16, r.Uint32, r.Uint64:
+		return self.typed(rval.Uint()), true
+	case r.Float32:
+		return self.typed(float32(rval.Float())), true
+	case r.Float64:
+		return self.typed(rval.Float()), true
+	default:
+		return self, false
+	}
+}
+
+func (self Attr) json(val any) Attr {
+	out, err := json.Marshal(val)
+	if err != nil {
+		panic(self.encodeErr(val, err))
+	}
+	return self.Set(string(out))
+}
+
+/*
+True if "data-" followed by the input is a valid custom data attribute name.
+Reference:
+
+	https://html.spec.whatwg.org/multipage/dom.html#embedding-custom-non-visible-data-with-the-data-*-attributes
+*/
+func isDataSuffix(val string) bool {
+	return val != `` && !hasUpper(val) && isXmlNcname(`data-`+val)
+}
+
+// True if "aria-" followed by the input is a plausible ARIA attribute name.
+func isAriaSuffix(val string) bool {
+	for ind := 0; ind < len(val); ind++ {
+		if val[ind] < 'a' || val[ind] > 'z' {
+			return false
+		}
+	}
+	return val != ``
+}
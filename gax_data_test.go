@@ -0,0 +1,84 @@
+package gax
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+type dataKind string
+
+type dataConf struct {
+	Name  string   `json:"name"`
+	Tags  []string `json:"tags,omitempty"`
+	Limit int      `json:"limit"`
+}
+
+func TestData(t *testing.T) {
+	test := func(attr Attr, exp string) {
+		t.Helper()
+		eqs(t, attr, exp)
+	}
+
+	test(Data(`one`, nil), ``)
+	test(Data(`one`, `<two> & "three"`), ` data-one="<two> &amp; &quot;three&quot;"`)
+	test(Data(`one-two`, 10), ` data-one-two="10"`)
+	test(Data(`one.two_three`, 1.5), ` data-one.two_three="1.5"`)
+	test(Data(`one`, true), ` data-one="true"`)
+	test(Data(`one`, false), ` data-one="false"`)
+	test(Data(`one`, FlagOn), ` data-one=""`)
+	test(Data(`one`, dataKind(`two`)), ` data-one="two"`)
+	test(Data(`one`, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)), ` data-one="2024-01-02T03:04:05Z"`)
+	test(Data(`one`, Class{`two`, `three`}), ` data-one="two three"`)
+	test(Data(`one`, Url(`javascript:alert(1)`)), ` data-one="javascript:alert(1)"`)
+
+	test(Data(`conf`, dataConf{Name: `<one>`, Limit: 2}), ` data-conf="{&quot;name&quot;:&quot;\u003cone\u003e&quot;,&quot;limit&quot;:2}"`)
+	test(Data(`conf`, &dataConf{Name: `one`, Tags: []string{`two`}}), ` data-conf="{&quot;name&quot;:&quot;one&quot;,&quot;tags&quot;:[&quot;two&quot;],&quot;limit&quot;:0}"`)
+	test(Data(`one`, map[string]int{`two`: 2, `three`: 3}), ` data-one="{&quot;three&quot;:3,&quot;two&quot;:2}"`)
+	test(Data(`one`, []int{1, 2}), ` data-one="[1,2]"`)
+	test(Data(`one`, []string(nil)), ` data-one="null"`)
+	test(Data(`one`, (*dataConf)(nil)), ` data-one="null"`)
+
+	eq(t, AP(`class`, `one`).A(Data(`two`, 3), Aria(`hidden`, true)), AP(`class`, `one`, `data-two`, `3`, `aria-hidden`, `true`))
+}
+
+func TestAria(t *testing.T) {
+	test := func(attr Attr, exp string) {
+		t.Helper()
+		eqs(t, attr, exp)
+	}
+
+	test(Aria(`hidden`, nil), ``)
+	test(Aria(`hidden`, true), ` aria-hidden="true"`)
+	test(Aria(`expanded`, false), ` aria-expanded="false"`)
+	test(Aria(`label`, `one "two"`), ` aria-label="one &quot;two&quot;"`)
+	test(Aria(`level`, 2), ` aria-level="2"`)
+	test(Aria(`valuenow`, 0.25), ` aria-valuenow="0.25"`)
+	test(Aria(`describedby`, []string{`one`, `two`}), ` aria-describedby="one two"`)
+	test(Aria(`current`, dataKind(`page`)), ` aria-current="page"`)
+
+	eqs(t, (&Ctx{Minify: true}).F(E(`div`, A(Aria(`hidden`, false)))), `<div aria-hidden=false></div>`)
+	eqs(t, (&Ctx{Dialect: DialectHtml}).F(E(`div`, A(Aria(`hidden`, false)))), `<div aria-hidden="false"></div>`)
+}
+
+func TestData_panic(t *testing.T) {
+	test := func(msg string, fun func()) {
+		t.Helper()
+		defer func() {
+			t.Helper()
+			eq(t, fmt.Sprint(recover()), msg)
+		}()
+		fun()
+	}
+
+	test(`[gax] invalid attribute name "data-" (html)`, func() { Data(``, 1) })
+	test(`[gax] invalid attribute name "data-One" (html)`, func() { Data(`One`, 1) })
+	test(`[gax] invalid attribute name "data-one two" (html)`, func() { Data(`one two`, 1) })
+	test(`[gax] invalid attribute name "data-one:two" (html)`, func() { Data(`one:two`, 1) })
+	test(`[gax] invalid attribute name "aria-" (html)`, func() { Aria(``, 1) })
+	test(`[gax] invalid attribute name "aria-one-two" (html)`, func() { Aria(`one-two`, 1) })
+	test(`[gax] invalid attribute name "aria-Hidden" (html)`, func() { Aria(`Hidden`, 1) })
+	test(`[gax] unsupported value type map[string]int for attribute "aria-one"`, func() { Aria(`one`, map[string]int{}) })
+	test(`[gax] failed to encode value of type []float64 for attribute "data-one": json: unsupported value: NaN`, func() { Data(`one`, []float64{math.NaN()}) })
+}
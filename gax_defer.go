@@ -0,0 +1,200 @@
+package gax
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+/*
+Short for "deferred". Wraps a potentially slow `Ren` for out-of-order streaming.
+Returns a `Deferred`, which implements `Ren` and can be passed as a child to
+`E`, `F`, `Bui.E` and so on.
+
+When rendered by a `Stream`, this immediately writes the given fallback
+(placeholder) content, and renders the wrapped `Ren` concurrently, while the
+rest of the document keeps streaming. Finished subtrees are written at the
+next flush point of the stream, or, at the latest, by `Stream.Close`, in order
+of completion, along with a tiny inline script that swaps each subtree into
+place of its placeholder. This is similar to the "suspense" feature of some JS
+libraries. Flush points inside elements whose content isn't regular HTML
+markup, such as `script`, `textarea`, `template` or `svg`, are skipped.
+
+When rendered by a regular `Bui`, there is nothing to gain from deferral, and
+the wrapped `Ren` is rendered in place, synchronously, without a placeholder.
+
+The wrapped `Ren` runs on another goroutine, and must not share its `*Bui`
+with anything else. It's rendered with a copy of the stream's `Ctx` settings,
+if any, and with the values of `Bui.Context` at the point of deferral. A panic
+during its rendering is re-raised on the goroutine that calls `Stream.Close`,
+never at earlier flush points.
+*/
+func Defer(fallback any, val Ren) Deferred { return Deferred{fallback, val} }
+
+// Implementation of `Defer`. See `Defer` for details.
+type Deferred struct {
+	Fallback any
+	Ren      Ren
+}
+
+// Implement `Ren`. See `Defer` for details.
+func (self Deferred) Render(bui *Bui) {
+	if self.Ren == nil {
+		bui.Child(self.Fallback)
+		return
+	}
+
+	st := stateOf(bui)
+	if st == nil || st.stream == nil {
+		self.Ren.Render(bui)
+		return
+	}
+
+	id := st.stream.defers.start(st.ctx, st.context, self.Ren)
+	bui.E(`template`, AP(`id`, deferId(`gax-d`, id)))
+	bui.Child(self.Fallback)
+	bui.NonEscString(`<!--/`)
+	bui.NonEscString(deferId(`gax-d`, id))
+	bui.NonEscString(`-->`)
+}
+
+/*
+Swaps deferred content into place of the placeholder, removing the fallback
+between the placeholder and its end marker.
+*/
+const deferScript = `function gaxSwap(i){var a=document.getElementById("gax-d"+i),b=document.getElementById("gax-r"+i);if(!a||!b)return;var p=a.parentNode,e="/gax-d"+i,n;while((n=a.nextSibling)&&!(n.nodeType===8&&n.data===e))p.removeChild(n);if(n)p.removeChild(n);p.replaceChild(b.content,a);b.parentNode.removeChild(b)}`
+
+func deferId(prefix string, id int) string { return prefix + strconv.Itoa(id) }
+
+/*
+Elements whose content isn't parsed as regular HTML markup, or is inert,
+where finished deferred subtrees can't be written. Includes `svg` and `math`
+for renders without a `Dialect`. Raw text elements are excluded separately,
+see `state.canDrain`.
+*/
+var deferOpaque = newStringSet(
+	`iframe`, `math`, `noembed`, `noframes`, `noscript`, `plaintext`, `svg`,
+	`template`, `textarea`, `title`, `xmp`,
+)
+
+/*
+Book-keeping of deferred renders started by a `Stream`. Rendering goroutines
+never block on this, even if the stream is abandoned without closing.
+*/
+type defers struct {
+	sync.Mutex
+	cond    sync.Cond
+	count   int
+	pending int
+	ready   []*deferred
+	script  bool
+}
+
+type deferred struct {
+	id   int
+	ctx  *Ctx
+	out  Bui
+	err  any
+	errs []error
+}
+
+/*
+Starts a deferred render with a copy of the given settings. Values scoped via
+`Bui.With` are carried over as `Ctx.Context`.
+*/
+func (self *defers) start(ctx *Ctx, scope context.Context, val Ren) int {
+	self.Lock()
+	if self.cond.L == nil {
+		self.cond.L = &self.Mutex
+	}
+	self.count++
+	self.pending++
+	id := self.count
+	self.Unlock()
+
+	ctx = ctx.fork()
+	if scope != nil {
+		if ctx == nil {
+			ctx = &Ctx{}
+		}
+		ctx.Context = scope
+	}
+
+	go self.run(&deferred{id: id, ctx: ctx}, val)
+	return id
+}
+
+func (self *defers) run(out *deferred, val Ren) {
+	defer func() {
+		out.err = recover()
+		self.Lock()
+		self.ready = append(self.ready, out)
+		self.cond.Signal()
+		self.Unlock()
+	}()
+
+	ctx := out.ctx
+	if ctx == nil {
+		ctx = &Ctx{}
+	}
+	ctx.into(&out.out, &out.errs, []any{val})
+}
+
+// Blocks until any deferred render is done. Returns nil if none are pending.
+func (self *defers) next() *deferred {
+	self.Lock()
+	defer self.Unlock()
+
+	if self.pending == 0 {
+		return nil
+	}
+
+	for len(self.ready) == 0 {
+		self.cond.Wait()
+	}
+	return self.take(0)
+}
+
+/*
+Returns a successfully finished deferred render without blocking, or nil if
+none. Failed renders are left for `Stream.Close`, which re-raises their panics;
+see `Defer`.
+*/
+func (self *defers) poll() *deferred {
+	self.Lock()
+	defer self.Unlock()
+
+	for ind, val := range self.ready {
+		if val.err == nil {
+			return self.take(ind)
+		}
+	}
+	return nil
+}
+
+// Must be called with the lock held.
+func (self *defers) take(ind int) *deferred {
+	out := self.ready[ind]
+	self.ready = append(self.ready[:ind], self.ready[ind+1:]...)
+	self.pending--
+	return out
+}
+
+func (self *defers) resolve(bui *Bui, val *deferred) {
+	if val.err != nil {
+		panic(val.err)
+	}
+	st := stateOf(bui)
+	st.ctx.merge(val.ctx)
+	if st.errs != nil {
+		*st.errs = append(*st.errs, val.errs...)
+	}
+
+	if !self.script {
+		self.script = true
+		bui.E(`script`, nil, Str(deferScript))
+	}
+
+	bui.E(`template`, AP(`id`, deferId(`gax-r`, val.id)), val.out)
+	bui.E(`script`, nil, Str(`gaxSwap(`+strconv.Itoa(val.id)+`)`))
+}
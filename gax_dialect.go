@@ -0,0 +1,309 @@
+package gax
+
+import (
+	"sort"
+	"strings"
+)
+
+/*
+Describes a markup language: which elements are void, which attributes are
+boolean, which elements contain raw text, how names are validated, and how
+empty elements are written. Used when rendering with `Ctx.Dialect`, or for a
+subtree via `Dialect.Into`. Dialects are immutable and safe for concurrent use.
+For the built-in dialects, see `DialectHtml`, `DialectSvg`, `DialectMathMl`,
+`DialectXml`. For custom dialects, see `NewDialect` and `Dialect.Conf`.
+
+When no dialect is selected, rendering uses the global tables `Void`, `Bool` and
+`Raw`, for compatibility with older versions. The globals are mutable, and
+changing them affects every render in the program; prefer dialects.
+
+Some dialects switch to another dialect for specific subtrees, see
+`DialectConf.Sub`. For example, in `DialectHtml`, elements `svg` and `math`
+and their descendants use `DialectSvg` and `DialectMathMl`, matching how
+HTML parsers treat "foreign" content.
+*/
+type Dialect struct {
+	name  string
+	void  stringSet
+	bool  stringSet
+	raw   stringSet
+	sub   map[string]*Dialect
+	names Names
+	fold  bool
+	self  bool
+	xml   bool
+}
+
+/*
+Configuration of a `Dialect`, used by `NewDialect`. Obtain the configuration of
+an existing dialect via `Dialect.Conf` to derive custom dialects.
+*/
+type DialectConf struct {
+	// Name for debug purposes.
+	Name string
+
+	// Void elements, which have no content and no end tag. See `Void`.
+	Void []string
+
+	// Boolean attributes, written without a value. See `Bool`.
+	Bool []string
+
+	// Raw text elements, whose content is not escaped. See `Raw`.
+	Raw []string
+
+	// Elements whose subtrees use another dialect.
+	Sub map[string]*Dialect
+
+	// Rules for validating element and attribute names. See `Names`.
+	Names Names
+
+	// If true, lookups in the tables above ignore ASCII case, as in HTML.
+	Fold bool
+
+	// If true, elements without content are written as self-closing tags,
+	// such as "<path/>". Void elements are still written as such.
+	SelfClose bool
+
+	// If true, output uses XML syntax, as if by `Ctx.Xml`.
+	Xml bool
+}
+
+/*
+Creates an immutable `Dialect` from the given configuration. The input is
+copied, and modifying it later doesn't affect the dialect.
+*/
+func NewDialect(conf DialectConf) *Dialect {
+	out := &Dialect{
+		name:  conf.Name,
+		void:  dialectSet(conf.Void, conf.Fold),
+		bool:  dialectSet(conf.Bool, conf.Fold),
+		raw:   dialectSet(conf.Raw, conf.Fold),
+		names: conf.Names,
+		fold:  conf.Fold,
+		self:  conf.SelfClose || conf.Xml,
+		xml:   conf.Xml,
+	}
+
+	if len(conf.Sub) > 0 {
+		out.sub = make(map[string]*Dialect, len(conf.Sub))
+		for key, val := range conf.Sub {
+			out.sub[dialectKey(key, conf.Fold)] = val
+		}
+	}
+	return out
+}
+
+/*
+Returns a copy of the configuration of this dialect, which may be modified and
+passed to `NewDialect` to create a custom dialect. For nil, returns the current
+content of the global tables `Void`, `Bool` and `Raw`.
+*/
+func (self *Dialect) Conf() DialectConf {
+	if self == nil {
+		return DialectConf{
+			Void: setSlice(Void),
+			Bool: setSlice(Bool),
+			Raw:  setSlice(Raw),
+		}
+	}
+
+	out := DialectConf{
+		Name:      self.name,
+		Void:      setSlice(self.void),
+		Bool:      setSlice(self.bool),
+		Raw:       setSlice(self.raw),
+		Names:     self.names,
+		Fold:      self.fold,
+		SelfClose: self.self,
+		Xml:       self.xml,
+	}
+
+	if len(self.sub) > 0 {
+		out.Sub = make(map[string]*Dialect, len(self.sub))
+		for key, val := range self.sub {
+			out.Sub[key] = val
+		}
+	}
+	return out
+}
+
+// Returns the name of this dialect, for debug purposes.
+func (self *Dialect) Name() string {
+	if self == nil {
+		return ``
+	}
+	return self.name
+}
+
+// Implement `fmt.Stringer` for debug purposes. Same as `Dialect.Name`.
+func (self *Dialect) String() string { return self.Name() }
+
+/*
+True if the given element is void in this dialect. For nil, uses the global
+`Void`.
+*/
+func (self *Dialect) IsVoid(tag string) bool {
+	if self == nil {
+		return Void.Has(tag)
+	}
+	return self.void.Has(dialectKey(tag, self.fold))
+}
+
+/*
+True if the given attribute is boolean in this dialect. For nil, uses the
+global `Bool`.
+*/
+func (self *Dialect) IsBool(key string) bool {
+	if self == nil {
+		return Bool.Has(key)
+	}
+	return self.bool.Has(dialectKey(key, self.fold))
+}
+
+/*
+True if the given element contains raw text in this dialect. For nil, uses the
+global `Raw`.
+*/
+func (self *Dialect) IsRaw(tag string) bool {
+	if self == nil {
+		return isRaw(tag)
+	}
+	return self.raw.Has(dialectKey(tag, self.fold))
+}
+
+/*
+Returns the dialect used for the given element and its descendants. See
+`DialectConf.Sub`.
+*/
+func (self *Dialect) Sub(tag string) *Dialect {
+	if self != nil && self.sub != nil {
+		if val := self.sub[dialectKey(tag, self.fold)]; val != nil {
+			return val
+		}
+	}
+	return self
+}
+
+/*
+Similar to the function `F`, but renders with this dialect. Shortcut for
+`Dialect.Into`.
+*/
+func (self *Dialect) F(vals ...any) (bui Bui) {
+	self.Into(&bui, vals...)
+	return
+}
+
+/*
+Renders the given children into the given builder, as if by calling `Bui.F`,
+using this dialect for the duration of the call. Can be used for rendering a
+subtree in another dialect. Preserves the `Ctx` of the current render, if any.
+*/
+func (self *Dialect) Into(bui *Bui, vals ...any) {
+	st := stateOf(bui)
+	if st == nil {
+		withState(bui, &state{dialect: self}, func(bui *Bui) { bui.F(vals...) })
+		return
+	}
+
+	prev := st.dialect
+	st.dialect = self
+	defer func() { st.dialect = prev }()
+
+	bui.F(vals...)
+}
+
+func (self *Dialect) getNames() Names {
+	if self == nil {
+		return NamesPermissive
+	}
+	return self.names
+}
+
+// True if elements without content are self-closing.
+func (self *Dialect) selfClose() bool { return self != nil && self.self }
+
+// True if output must use XML syntax.
+func (self *Dialect) isXml() bool { return self != nil && self.xml }
+
+/*
+True for dialects other than HTML, where HTML-specific formatting and
+minification don't apply. See `Ctx.Indent` and `Ctx.Minify`.
+*/
+func (self *Dialect) foreign() bool { return self != nil && (self.self || self.xml) }
+
+func dialectKey(val string, fold bool) string {
+	if fold && hasUpper(val) {
+		return strings.ToLower(val)
+	}
+	return val
+}
+
+func dialectSet(vals []string, fold bool) stringSet {
+	out := make(stringSet, len(vals))
+	for _, val := range vals {
+		out.Add(dialectKey(val, fold))
+	}
+	return out
+}
+
+func setSlice(set stringSet) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(set))
+	for key := range set {
+		out = append(out, key)
+	}
+	sort.Strings(out)
+	return out
+}
+
+/*
+Dialect for HTML documents, following the HTML Living Standard. Validates names
+via `NamesHtml`. Elements `svg` and `math` switch to `DialectSvg` and
+`DialectMathMl`.
+*/
+var DialectHtml = NewDialect(DialectConf{
+	Name:  `html`,
+	Names: NamesHtml,
+	Void: []string{
+		`area`, `base`, `br`, `col`, `embed`, `hr`, `img`, `input`, `link`,
+		`meta`, `param`, `source`, `track`, `wbr`,
+	},
+	Bool: []string{
+		`allowfullscreen`, `async`, `autofocus`, `autoplay`, `checked`,
+		`controls`, `default`, `defer`, `disabled`, `formnovalidate`, `hidden`,
+		`inert`, `ismap`, `itemscope`, `loop`, `multiple`, `muted`, `nomodule`,
+		`novalidate`, `open`, `playsinline`, `readonly`, `required`, `reversed`,
+		`selected`, `shadowrootclonable`, `shadowrootdelegatesfocus`,
+		`shadowrootserializable`,
+	},
+	Raw:  []string{`script`, `style`},
+	Fold: true,
+})
+
+/*
+Dialect for SVG, either embedded in HTML or standalone. Has no void, boolean or
+raw text elements. Elements without content are self-closing. Validates names
+via `NamesXml`. The element `foreignObject` switches to `DialectHtml`.
+*/
+var DialectSvg = NewDialect(DialectConf{Name: `svg`, Names: NamesXml, SelfClose: true})
+
+/*
+Dialect for MathML, either embedded in HTML or standalone. Has no void, boolean
+or raw text elements. Elements without content are self-closing. Validates
+names via `NamesXml`.
+*/
+var DialectMathMl = NewDialect(DialectConf{Name: `mathml`, Names: NamesXml, SelfClose: true})
+
+/*
+Dialect for generic XML, such as feeds or sitemaps. Has no void, boolean or raw
+text elements, and uses XML syntax, as if by `Ctx.Xml`. Validates names via
+`NamesXml`.
+*/
+var DialectXml = NewDialect(DialectConf{Name: `xml`, Names: NamesXml, Xml: true})
+
+func init() {
+	DialectHtml.sub = map[string]*Dialect{`svg`: DialectSvg, `math`: DialectMathMl}
+	DialectSvg.sub = map[string]*Dialect{`foreignObject`: DialectHtml}
+}
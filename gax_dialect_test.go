@@ -0,0 +1,101 @@
+package gax
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDialect(t *testing.T) {
+	ctx := Ctx{Dialect: DialectHtml}
+
+	eqs(
+		t,
+		ctx.F(
+			E(`BR`, nil),
+			E(`input`, AP(`inert`, `true`)),
+			E(`svg`, AP(`viewBox`, `0 0 1 1`),
+				E(`path`, AP(`d`, `M0`)),
+				E(`style`, nil, `a > b {}`),
+				E(`foreignObject`, nil, E(`br`, nil), E(`div`, nil)),
+			),
+			E(`math`, nil, E(`mi`, nil, `x`), E(`mspace`, nil)),
+			E(`div`, nil),
+		),
+		`<BR><input inert=""><svg viewBox="0 0 1 1"><path d="M0"/><style>a &gt; b {}</style><foreignObject><br><div></div></foreignObject></svg><math><mi>x</mi><mspace/></math><div></div>`,
+	)
+
+	eqs(t, F(E(`svg`, nil, E(`path`, nil))), `<svg><path></path></svg>`)
+	eqs(t, DialectSvg.F(E(`path`, nil), E(`br`, nil)), `<path/><br/>`)
+	eqs(
+		t,
+		DialectXml.F(Str(XmlDecl), E(`feed`, nil, E(`link`, AP(`href`, `/one`)), E(`title`, nil, `one's`))),
+		`<?xml version="1.0" encoding="UTF-8"?><feed><link href="/one"/><title>one&#39;s</title></feed>`,
+	)
+}
+
+func TestDialect_Into(t *testing.T) {
+	var bui Bui
+	bui.E(`div`, nil, func(bui *Bui) {
+		DialectSvg.Into(bui, E(`svg`, nil), func(bui *Bui) { bui.E(`path`, nil) })
+		bui.E(`span`, nil)
+	})
+	eqs(t, bui, `<div><svg/><path/><span></span></div>`)
+	eq(t, stateOf(&bui), nil)
+
+	ctx := Ctx{Nonce: `abc`}
+	eqs(
+		t,
+		ctx.F(func(bui *Bui) {
+			DialectXml.Into(bui, E(`one`, nil), func(bui *Bui) { bui.E(`script`, nil, `a < b`) })
+			bui.E(`script`, nil, `a < b`)
+		}),
+		`<one/><script nonce="abc">a &lt; b</script><script nonce="abc">a < b</script>`,
+	)
+}
+
+func TestDialect_Into_closure(t *testing.T) {
+	var bui Bui
+	E := bui.E
+
+	DialectSvg.Into(&bui, func() { E(`svg`, nil, func() { E(`path`, nil) }) }, `tail`)
+	eqs(t, bui, `<svg><path/></svg>tail`)
+	eq(t, stateOf(&bui), nil)
+}
+
+func TestNewDialect(t *testing.T) {
+	conf := DialectHtml.Conf()
+	eq(t, conf.Name, `html`)
+	eq(t, conf.Fold, true)
+	eq(t, conf.Sub[`svg`], DialectSvg)
+
+	conf.Name = `custom`
+	conf.Void = append(conf.Void, `my-void`)
+	conf.Bool = append(conf.Bool, `my-bool`)
+	conf.Raw = append(conf.Raw, `my-raw`)
+	custom := NewDialect(conf)
+
+	conf.Void = nil
+	eq(t, custom.String(), `custom`)
+	eq(t, custom.IsVoid(`MY-VOID`), true)
+	eq(t, DialectHtml.IsVoid(`my-void`), false)
+
+	eqs(
+		t,
+		(&Ctx{Dialect: custom}).F(E(`my-void`, AP(`my-bool`, ``)), E(`my-raw`, nil, `<b>`)),
+		`<my-void my-bool=""><my-raw><b></my-raw>`,
+	)
+
+	eq(t, strings.Join((*Dialect)(nil).Conf().Raw, ` `), `script style`)
+	eq(t, (*Dialect)(nil).IsBool(`checked`), true)
+}
+
+func TestDialect_Stream(t *testing.T) {
+	var buf strings.Builder
+	out := Stream{Wri: &buf, Ctx: &Ctx{Dialect: DialectHtml}}
+
+	out.E(`svg`, nil, E(`g`, nil))
+	out.F(func(bui *Bui) { bui.E(`svg`, nil, func(bui *Bui) { bui.E(`g`, nil) }) })
+	eq(t, out.Close(), nil)
+
+	eq(t, buf.String(), `<svg><g/></svg><svg><g/></svg>`)
+}
@@ -1,6 +1,9 @@
 package gax
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 /*
 Primary API. Short for "element" or "HTML element". Expresses an HTML/XML tag,
@@ -54,6 +57,24 @@ func (self Elem) AttrAdd(key, val string) Elem {
 	return self
 }
 
+// Shortcut for `Attrs.Get` on `.Attrs`.
+func (self Elem) AttrGet(key string) string { return self.Attrs.Get(key) }
+
+// Shortcut for `Attrs.Has` on `.Attrs`.
+func (self Elem) AttrHas(key string) bool { return self.Attrs.Has(key) }
+
+// Returns a modified version where `.Attrs` are modified via `Attrs.Del`.
+func (self Elem) AttrDel(key string) Elem {
+	self.Attrs = self.Attrs.Del(key)
+	return self
+}
+
+// Returns a modified version where `.Attrs` are modified via `Attrs.Merge`.
+func (self Elem) AttrMerge(vals Attrs) Elem {
+	self.Attrs = self.Attrs.Merge(vals)
+	return self
+}
+
 /*
 Implement `fmt.GoStringer` for debug purposes. Not used by builder methods.
 Represents itself as a call to `E`, which is the recommended way to write
@@ -70,6 +91,63 @@ func (self Elem) GoString() string {
 	return buf.String()
 }
 
+/*
+Short for "fragment". Sequence of children without a wrapping element, such as
+`Elem`, text as `string`, and so on. Implements `Ren` by rendering the children
+as if by `F`. Returned by functions which produce trees rather than markup,
+such as `Policy.Sanitize`.
+*/
+type Frag []any
+
+var _ = Ren(Frag(nil))
+
+// Implement `Ren`. Renders the children as if by `Bui.F`.
+func (self Frag) Render(b *Bui) { b.F(self...) }
+
+// Implement `fmt.Stringer` for debug purposes. Not used by builder methods.
+func (self Frag) String() string { return F(self).String() }
+
+/*
+Implement `fmt.GoStringer` for debug purposes. Not used by builder methods.
+Represents itself as a `Frag` literal.
+*/
+func (self Frag) GoString() string {
+	if self == nil {
+		return `Frag(nil)`
+	}
+
+	buf := appendElemChild(nil, []any(self))
+	return `Frag{` + strings.TrimPrefix(buf.String(), `, `) + `}`
+}
+
+/*
+Represents an HTML/XML comment. Implements `Ren` by rendering itself as
+"<!--" + content + "-->". Content which would end the comment early, such as
+"-->", causes a panic. Produced by `ParseHtml` and `ParseXml`.
+*/
+type Comment string
+
+var _ = Ren(Comment(``))
+
+// Implement `Ren`. See `Comment` for details.
+func (self Comment) Render(b *Bui) {
+	validComment(string(self))
+	b.NonEscString(`<!--`)
+	b.NonEscString(string(self))
+	b.NonEscString(`-->`)
+}
+
+// Implement `fmt.Stringer` for debug purposes. Not used by builder methods.
+func (self Comment) String() string { return F(self).String() }
+
+/*
+Implement `fmt.GoStringer` for debug purposes. Not used by builder methods.
+Represents itself as a conversion to `Comment`.
+*/
+func (self Comment) GoString() string {
+	return `Comment(` + string(appendQuote(nil, string(self))) + `)`
+}
+
 func appendElemChild(buf NonEscWri, val any) NonEscWri {
 	switch val := val.(type) {
 	case nil:
@@ -0,0 +1,315 @@
+package gax
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/*
+Short for "fallible renderer". Similar to `Ren`, but for renderers which may
+fail, such as components which perform IO. On children implementing this
+interface, the `TryRender` method is called, and a non-nil error is reported
+via `Bui.Fail`. Functions of type `func(*Bui) error` are treated the same way.
+*/
+type TryRen interface{ TryRender(*Bui) error }
+
+/*
+Error reported via `Bui.Fail`, annotated with the names of the elements which
+were open at the time, outermost first. Supports `errors.Is` and `errors.As`
+via `RenderError.Unwrap`.
+*/
+type RenderError struct {
+	Err  error
+	Path []string
+}
+
+// Implement `error`.
+func (self RenderError) Error() string {
+	var buf strings.Builder
+	buf.WriteString(`[gax] render error`)
+	if len(self.Path) > 0 {
+		fmt.Fprintf(&buf, ` at %q`, strings.Join(self.Path, ` > `))
+	}
+	if self.Err != nil {
+		buf.WriteString(`: `)
+		buf.WriteString(self.Err.Error())
+	}
+	return buf.String()
+}
+
+// Implement a hidden interface used by `errors.Is` and `errors.As`.
+func (self RenderError) Unwrap() error { return self.Err }
+
+/*
+Reports a rendering error, annotated with the current element path as
+`RenderError`. Nil is ignored. Rendering continues, so that a failing
+component doesn't necessarily break the rest of the document, and all errors
+are collected. They're returned by `TryF`, `Ctx.TryF` and `Stream.Close`.
+When rendering via other means, nothing collects errors, and this panics with
+the `RenderError`.
+*/
+func (self *Bui) Fail(err error) {
+	if err == nil {
+		return
+	}
+
+	st := stateOf(self)
+	out := RenderError{Err: err, Path: st.path()}
+	if st == nil || st.errs == nil {
+		panic(out)
+	}
+	*st.errs = append(*st.errs, out)
+}
+
+/*
+Returns the first error reported via `Bui.Fail` in the current render, if
+any. Useful for components which need to react to earlier failures.
+*/
+func (self *Bui) Err() error {
+	if errs := self.Errs(); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// Returns all errors reported via `Bui.Fail` in the current render, in order.
+func (self *Bui) Errs() []error {
+	if st := stateOf(self); st != nil && st.errs != nil {
+		return *st.errs
+	}
+	return nil
+}
+
+/*
+Similar to the function `F`, but collects errors reported via `Bui.Fail`,
+including errors returned by `TryRen` children, and returns them.
+Additionally, panics with the error types of this package, such as
+`NameError`, `PairError` or `ChildError`, stop the render and are returned as
+errors; other panics are re-raised. With multiple errors, they're combined via
+`errors.Join`. The output is returned even on failure, and may be incomplete.
+Usage in an HTTP handler:
+
+	body, err := gax.TryF(Page(dat))
+	if err != nil {
+		http.Error(rew, `internal server error`, http.StatusInternalServerError)
+		return
+	}
+*/
+func TryF(vals ...any) (Bui, error) { return (*Ctx)(nil).TryF(vals...) }
+
+/*
+Similar to `Ctx.F`, but collects errors reported via `Bui.Fail`, like `TryF`.
+Nil-safe: for nil, renders without a context.
+*/
+func (self *Ctx) TryF(vals ...any) (bui Bui, err error) {
+	if self == nil {
+		self = &Ctx{}
+	}
+
+	var errs []error
+	defer func() {
+		if val := recover(); val != nil {
+			errs = append(errs, recoverErr(val))
+		}
+		err = joinErrs(errs)
+	}()
+
+	self.into(&bui, &errs, vals)
+	return bui, nil
+}
+
+/*
+Error used in panics when attributes are given as pairs, such as via `AP` or
+`AV`, and the argument count is odd.
+*/
+type PairError struct {
+	// Name of the function, such as "Attrs.AP".
+	Func string
+
+	// Offending arguments, such as `[]string` for `AP` or `[]any` for `AV`.
+	Args any
+}
+
+// Implement `error`.
+func (self PairError) Error() string {
+	return fmt.Sprintf(`[gax] %v expects an even amount of args, got %#v`, self.Func, self.Args)
+}
+
+/*
+Error used in panics when a child can't be rendered, such as a function of an
+unsupported signature. See `Bui.E` for the supported children.
+*/
+type ChildError struct {
+	// Offending child.
+	Val any
+
+	// Names of the open elements, outermost first, if known.
+	Path []string
+}
+
+// Implement `error`.
+func (self ChildError) Error() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, `[gax] can't render %T`, self.Val)
+	if len(self.Path) > 0 {
+		fmt.Fprintf(&buf, ` at %q`, strings.Join(self.Path, ` > `))
+	}
+	return buf.String()
+}
+
+/*
+Error used in panics when content can't be embedded in a raw text element,
+such as `script`, without changing how it's parsed. See `Bui.E` for the rules.
+*/
+type RawError struct {
+	// Name of the raw text element, such as "script".
+	Tag string
+
+	// Offending sequence of the content, such as "</script", if any.
+	Text string
+
+	// Offending child, if its type is not allowed in this element.
+	Val any
+
+	// Names of the open elements, outermost first, if known.
+	Path []string
+}
+
+// Implement `error`.
+func (self RawError) Error() string {
+	var buf strings.Builder
+
+	switch {
+	case self.Text != ``:
+		fmt.Fprintf(&buf, `[gax] can't embed %q in raw text element %q`, self.Text, self.Tag)
+	case rawTarget(self.Val) != ``:
+		fmt.Fprintf(&buf, `[gax] can't embed content intended for %q in raw text element %q`, rawTarget(self.Val), self.Tag)
+	default:
+		fmt.Fprintf(&buf, `[gax] can't embed %T in raw text element %q`, self.Val, self.Tag)
+	}
+
+	if len(self.Path) > 0 {
+		fmt.Fprintf(&buf, ` at %q`, strings.Join(self.Path, ` > `))
+	}
+	return buf.String()
+}
+
+// Error used in panics when rendering a `Comment` whose content is invalid.
+type CommentError struct{ Text string }
+
+// Implement `error`.
+func (self CommentError) Error() string {
+	return fmt.Sprintf(`[gax] invalid comment %q`, self.Text)
+}
+
+/*
+Error used in panics when a value can't be encoded as an attribute, for
+example when its type is not supported by `AV`, when it's a non-finite float,
+or when it's an invalid `Class` or `Style`. Also used when `AS` or
+`Attrs.Decode` is given an unsupported type. Supports `errors.Is` and
+`errors.As` via `AttrError.Unwrap`.
+*/
+type AttrError struct {
+	// Name of the attribute, if known.
+	Name string
+
+	// Offending value, or offending name for unsupported name types, or
+	// offending type for unsupported struct fields.
+	Val any
+
+	// Description of the problem, possibly wrapping an underlying error, such
+	// as from JSON encoding.
+	Err error
+}
+
+// Implement `error`.
+func (self AttrError) Error() string {
+	if self.Err == nil {
+		return fmt.Sprintf(`[gax] invalid value %v for attribute %q`, self.Val, self.Name)
+	}
+	return `[gax] ` + self.Err.Error()
+}
+
+// Implement a hidden interface used by `errors.Is` and `errors.As`.
+func (self AttrError) Unwrap() error { return self.Err }
+
+/*
+Similar to `E`, but renders the element immediately, as if by `TryF`, and
+returns the result. Converts panics with the error types of this package into
+errors; see `TryF`.
+*/
+func TryE(tag string, attrs Attrs, children ...any) (Bui, error) {
+	return TryF(E(tag, attrs, children...))
+}
+
+/*
+Similar to `Bui.E`, but converts panics with the error types of this package
+into errors; see `TryF`. On such errors, the partial output of the element is
+removed, unless it was already flushed by a `Stream`, and the builder remains
+usable. Errors reported via `Bui.Fail` are collected as usual and are not
+returned by this method.
+*/
+func (self *Bui) TryE(tag string, attrs Attrs, children ...any) (err error) {
+	start := len(*self)
+	st := stateOf(self)
+	var snap state
+	var flushes int
+	if st != nil {
+		snap = *st
+		flushes = st.mark(self).flushes
+	}
+
+	defer func() {
+		val := recover()
+		if val == nil {
+			return
+		}
+		err = recoverErr(val)
+
+		if st != nil {
+			if st.mark(self).flushes == flushes {
+				*self = (*self)[:start]
+			}
+			st.stack = snap.stack[:len(snap.stack):len(snap.stack)]
+			st.tails = nil
+			st.raw = snap.raw
+			st.dialect = snap.dialect
+			st.context = snap.context
+		} else {
+			*self = (*self)[:start]
+			if stateOf(self) != nil {
+				states.swap(self, nil)
+			}
+		}
+	}()
+
+	self.E(tag, attrs, children...)
+	return nil
+}
+
+func (self *Bui) tryRender(val TryRen) { self.Fail(val.TryRender(self)) }
+
+/*
+Converts a recovered panic with one of the error types of this package into
+an error. Other panics are re-raised.
+*/
+func recoverErr(val any) error {
+	switch val := val.(type) {
+	case NameError, PairError, ChildError, RenderError, RawError, CommentError, AttrError:
+		return val.(error)
+	default:
+		panic(val)
+	}
+}
+
+func joinErrs(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return errors.Join(errs...)
+	}
+}
@@ -0,0 +1,192 @@
+package gax
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+type tryRen struct{ err error }
+
+func (self tryRen) TryRender(bui *Bui) error {
+	bui.T(`try`)
+	return self.err
+}
+
+func TestTryF(t *testing.T) {
+	out, err := TryF(E(`div`, nil, `one`, tryRen{}, func(*Bui) error { return nil }))
+	eqs(t, out, `<div>onetry</div>`)
+	eq(t, err, nil)
+
+	out, err = TryF(
+		E(`div`, nil,
+			E(`p`, nil, tryRen{io.EOF}),
+			func(bui *Bui) error {
+				eq(t, bui.Err(), error(RenderError{io.EOF, []string{`div`, `p`}}))
+				return io.ErrUnexpectedEOF
+			},
+		),
+		E(`script`, nil, tryRen{io.ErrClosedPipe}),
+	)
+
+	eqs(t, out, `<div><p>try</p></div><script>try</script>`)
+	eq(t, err.Error(), "[gax] render error at \"div > p\": EOF\n[gax] render error at \"div\": unexpected EOF\n[gax] render error at \"script\": io: read/write on closed pipe")
+	eq(t, errors.Is(err, io.EOF), true)
+	eq(t, errors.Is(err, io.ErrClosedPipe), true)
+
+	var rerr RenderError
+	eq(t, errors.As(err, &rerr), true)
+	eq(t, rerr, RenderError{io.EOF, []string{`div`, `p`}})
+
+	_, err = TryF(func(bui *Bui) { bui.Fail(io.EOF) })
+	eq(t, err, error(RenderError{Err: io.EOF}))
+	eq(t, err.Error(), `[gax] render error: EOF`)
+}
+
+func TestCtx_TryF(t *testing.T) {
+	ctx := Ctx{Nonce: `abc`, Minify: true}
+
+	out, err := ctx.TryF(E(`html`, nil, E(`script`, nil), func(bui *Bui) error {
+		(&Ctx{}).Into(bui, tryRen{io.EOF})
+		return nil
+	}))
+
+	eqs(t, out, `<html><script nonce=abc></script>try`)
+	eq(t, err, error(RenderError{io.EOF, []string{`html`}}))
+
+	out, err = (*Ctx)(nil).TryF(`one`)
+	eqs(t, out, `one`)
+	eq(t, err, nil)
+}
+
+func TestBui_Fail(t *testing.T) {
+	var bui Bui
+	bui.Fail(nil)
+	eq(t, bui.Err(), nil)
+	eq(t, bui.Errs(), nil)
+
+	defer func() {
+		eq(t, recover(), any(RenderError{io.EOF, []string{`div`}}))
+		eqs(t, bui, `<div>try`)
+	}()
+	(&Ctx{}).Into(&bui, E(`div`, nil, tryRen{io.EOF}))
+	t.Fatal(`unreachable`)
+}
+
+func TestTryRen_Stream(t *testing.T) {
+	var buf strings.Builder
+	out := Stream{Wri: &buf}
+
+	out.E(`div`, nil, tryRen{io.EOF}, Defer(nil, E(`p`, nil, tryRen{io.ErrUnexpectedEOF})))
+	err := out.Close()
+
+	eq(t, fmt.Sprint(err), "[gax] render error at \"div\": EOF\n[gax] render error at \"p\": unexpected EOF")
+	eq(t, strings.HasPrefix(buf.String(), `<div>try<template id="gax-d1">`), true)
+}
+
+func TestTryF_panic(t *testing.T) {
+	out, err := TryF(E(`div`, nil, E(`p`, AP(`one two`, ``))))
+	eqs(t, out, `<div><p`)
+	eq(t, err, error(NameError{Tag: `p`, Attr: `one two`, Path: []string{`div`}}))
+
+	var nerr NameError
+	eq(t, errors.As(err, &nerr), true)
+	eq(t, nerr.Attr, `one two`)
+
+	_, err = (&Ctx{Dialect: DialectHtml}).TryF(E(`div`, nil, E(`one two`, nil)))
+	eq(t, err, error(NameError{Tag: `one two`, Path: []string{`div`}, Names: NamesHtml}))
+
+	_, err = TryF(func(bui *Bui) { bui.E(`div`, AP(`one`)) })
+	eq(t, err, error(PairError{`Attrs.AP`, []string{`one`}}))
+	eq(t, err.Error(), `[gax] Attrs.AP expects an even amount of args, got []string{"one"}`)
+
+	_, err = TryF(E(`div`, nil, E(`p`, nil, func(int) {})))
+	var cerr ChildError
+	eq(t, errors.As(err, &cerr), true)
+	eq(t, cerr.Path, []string{`div`, `p`})
+	eq(t, err.Error(), `[gax] can't render func(int) at "div > p"`)
+
+	_, err = TryF(tryRen{io.EOF}, func(*Bui) { panic(PairError{`Attrs.AV`, []any{`one`}}) })
+	eq(t, err.Error(), "[gax] render error: EOF\n[gax] Attrs.AV expects an even amount of args, got []interface {}{\"one\"}")
+
+	_, err = TryF(E(`div`, nil, E(`script`, nil, `"</script>"`)))
+	eq(t, err, error(RawError{Tag: `script`, Text: `</script`, Path: []string{`div`, `script`}}))
+	eq(t, err.Error(), `[gax] can't embed "</script" in raw text element "script" at "div > script"`)
+
+	_, err = TryF(E(`style`, nil, Js(`one`)))
+	eq(t, err, error(RawError{Tag: `style`, Val: Js(`one`), Path: []string{`style`}}))
+	eq(t, err.Error(), `[gax] can't embed content intended for "script" in raw text element "style" at "style"`)
+
+	_, err = TryF(E(`script`, nil, Url(`one`)))
+	eq(t, err.Error(), `[gax] can't embed gax.Url in raw text element "script" at "script"`)
+
+	_, err = TryF(Comment(`-->`))
+	eq(t, err, error(CommentError{`-->`}))
+
+	_, err = TryF(func(*Bui) { AV(`width`, math.Inf(1)) })
+	var aerr AttrError
+	eq(t, errors.As(err, &aerr), true)
+	eq(t, aerr.Name, `width`)
+	eq(t, aerr.Val, any(math.Inf(1)))
+	eq(t, err.Error(), `[gax] can't encode non-finite number +Inf for attribute "width"`)
+
+	_, err = TryF(func(*Bui) { Data(`one`, []float64{math.NaN()}) })
+	var jerr *json.UnsupportedValueError
+	eq(t, errors.As(err, &jerr), true)
+
+	_, err = TryF(func(*Bui) { _ = Style{{`1x`, `one`}}.String() })
+	eq(t, err.Error(), `[gax] invalid CSS property name "1x"`)
+
+	_, err = TryF(func(bui *Bui) { bui.E(`div`, AS(`one`)) })
+	eq(t, errors.As(err, &aerr), true)
+	eq(t, aerr.Val, any(`one`))
+	eq(t, err.Error(), `[gax] AS expects a struct or a pointer to a struct, got string`)
+
+	_, err = TryF(func(*Bui) { _ = AP(`one`, `two`).Decode(&struct{ One []int `gax:"one"` }{}) })
+	eq(t, errors.As(err, &aerr), true)
+	eq(t, err.Error(), `[gax] unsupported field type []int for attribute decoding`)
+
+	defer func() { eq(t, recover(), any(`fail`)) }()
+	_, _ = TryF(func(*Bui) { panic(`fail`) })
+	t.Fatal(`unreachable`)
+}
+
+func TestTryE(t *testing.T) {
+	out, err := TryE(`div`, AP(`class`, `one`), `two`)
+	eqs(t, out, `<div class="one">two</div>`)
+	eq(t, err, nil)
+
+	_, err = TryE(`div`, nil, func(bui *Bui) { bui.E(`p`, AV(`one`, `two`, `three`)) })
+	eq(t, err, error(PairError{`Attrs.AV`, []any{`one`, `two`, `three`}}))
+}
+
+func TestBui_TryE(t *testing.T) {
+	var bui Bui
+	eq(t, bui.TryE(`div`, nil, `one`), nil)
+	eq(t, fmt.Sprint(bui.TryE(`div`, nil, `two`, func(int) {})), `[gax] can't render func(int)`)
+	eqs(t, bui, `<div>one</div>`)
+
+	ctx := Ctx{Indent: `  `}
+	eqs(
+		t,
+		ctx.F(E(`div`, nil, func(bui *Bui) {
+			err := bui.TryE(`ul`, nil, E(`li`, nil, E(`p`, nil, E(`one two`, nil))))
+			eq(t, err, error(NameError{Tag: `one two`, Path: []string{`div`, `ul`, `li`, `p`}}))
+			bui.E(`p`, nil, `three`)
+		})),
+		"<div>\n  <p>three</p>\n</div>",
+	)
+
+	var buf strings.Builder
+	out := Stream{Wri: &buf, Limit: 1}
+	out.E(`div`, nil, func(bui *Bui) {
+		eq(t, bui.TryE(`p`, nil, E(`span`, nil), func(int) {}) != nil, true)
+		bui.T(`one`)
+	})
+	must(out.Close())
+	eq(t, buf.String(), `<div><p><span></span>one</div>`)
+}
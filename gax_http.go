@@ -0,0 +1,180 @@
+package gax
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Content type used by `Handler` for HTML output.
+const TypeHtml = `text/html; charset=utf-8`
+
+// Content type used by `Handler` for XML output.
+const TypeXml = `application/xml; charset=utf-8`
+
+/*
+Shortcut for making a `Handler` that always renders the same `Ren`. Useful for
+static pages.
+*/
+func Handle(val Ren) Handler {
+	return Handler{Ren: func(*http.Request) Ren { return val }}
+}
+
+// Shortcut for making a `Handler` that renders a `Ren` per request.
+func HandleFunc(fun func(*http.Request) Ren) Handler {
+	return Handler{Ren: fun}
+}
+
+/*
+Implements `http.Handler` by rendering a `Ren` into a `Bui` and writing the
+result as the response body. Usually created via `Handle` or `HandleFunc`.
+Takes care of the usual HTTP glue:
+
+	* Sets "Content-Type". When `.Type` is empty, uses `TypeXml` for output
+	  starting with an XML declaration, and `TypeHtml` otherwise.
+	* Sets a strong "ETag" computed from the rendered bytes, and responds with
+	  304 to GET and HEAD requests with a matching "If-None-Match".
+	* When `.Gzip` is true, compresses the response if the client accepts gzip
+	  encoding. Since the ETag describes a specific representation, compressed
+	  responses use a different ETag.
+	* Renders via `Ctx.TryF`, with the request context as `Ctx.Context`. When
+	  rendering fails, the output is discarded, and `.OnErr` is called; when
+	  it's nil, responds with a plain 500 error.
+
+Since the ETag requires the entire body, this always buffers. For streaming, see
+`Stream`.
+*/
+type Handler struct {
+	Ren   func(*http.Request) Ren
+	Type  string
+	Gzip  bool
+	OnErr func(http.ResponseWriter, *http.Request, error)
+}
+
+// Implement `http.Handler`.
+func (self Handler) ServeHTTP(rew http.ResponseWriter, req *http.Request) {
+	var body Bui
+	if self.Ren != nil {
+		var err error
+		body, err = (&Ctx{Context: req.Context()}).TryF(self.Ren(req))
+		if err != nil {
+			self.fail(rew, req, err)
+			return
+		}
+	}
+
+	head := rew.Header()
+	zip := self.Gzip && acceptsGzip(req.Header.Get(`Accept-Encoding`))
+	tag := etag(body, zip)
+
+	if self.Gzip {
+		head.Add(`Vary`, `Accept-Encoding`)
+	}
+	head.Set(`ETag`, tag)
+
+	if isReqIdempotent(req) && etagMatch(req.Header.Get(`If-None-Match`), tag) {
+		rew.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	head.Set(`Content-Type`, self.contentType(body))
+
+	if zip {
+		body = gzipBytes(body)
+		head.Set(`Content-Encoding`, `gzip`)
+	}
+	head.Set(`Content-Length`, strconv.Itoa(len(body)))
+
+	rew.WriteHeader(http.StatusOK)
+	if req.Method != http.MethodHead {
+		_, _ = rew.Write(body)
+	}
+}
+
+func (self Handler) fail(rew http.ResponseWriter, req *http.Request, err error) {
+	if self.OnErr != nil {
+		self.OnErr(rew, req, err)
+		return
+	}
+	http.Error(rew, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+func (self Handler) contentType(body []byte) string {
+	if self.Type != `` {
+		return self.Type
+	}
+	if bytes.HasPrefix(body, []byte(`<?xml`)) {
+		return TypeXml
+	}
+	return TypeHtml
+}
+
+func isReqIdempotent(req *http.Request) bool {
+	return req.Method == http.MethodGet || req.Method == http.MethodHead
+}
+
+func etag(body []byte, zip bool) string {
+	sum := sha256.Sum256(body)
+	out := base64.RawURLEncoding.EncodeToString(sum[:16])
+	if zip {
+		return `"` + out + `-gzip"`
+	}
+	return `"` + out + `"`
+}
+
+/*
+Implements the "weak comparison" required for "If-None-Match" by RFC 9110,
+section 13.1.2.
+*/
+func etagMatch(header, tag string) bool {
+	for _, val := range strings.Split(header, `,`) {
+		val = strings.TrimSpace(val)
+		if val == `*` || strings.TrimPrefix(val, `W/`) == tag {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Checks the "Accept-Encoding" header for gzip support. An explicit "gzip" entry
+takes priority over the "*" wildcard. Either may be refused via "q=0".
+*/
+func acceptsGzip(header string) bool {
+	var wild bool
+
+	for _, val := range strings.Split(header, `,`) {
+		name, params, _ := strings.Cut(val, `;`)
+
+		switch strings.TrimSpace(name) {
+		case `gzip`, `x-gzip`:
+			return !isQualityZero(params)
+		case `*`:
+			wild = !isQualityZero(params)
+		}
+	}
+	return wild
+}
+
+func isQualityZero(params string) bool {
+	for _, val := range strings.Split(params, `;`) {
+		key, val, _ := strings.Cut(strings.TrimSpace(val), `=`)
+		if key == `q` {
+			num, err := strconv.ParseFloat(val, 64)
+			return err == nil && num == 0
+		}
+	}
+	return false
+}
+
+func gzipBytes(src []byte) []byte {
+	var buf bytes.Buffer
+	wri := gzip.NewWriter(&buf)
+	_, _ = wri.Write(src)
+	_ = wri.Close()
+	return buf.Bytes()
+}
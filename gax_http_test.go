@@ -0,0 +1,177 @@
+package gax
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	han := Handle(E(`div`, nil, `hello world!`))
+	rec := serve(han, httptest.NewRequest(http.MethodGet, `/`, nil))
+
+	eq(t, rec.Code, http.StatusOK)
+	eq(t, rec.Header().Get(`Content-Type`), TypeHtml)
+	eq(t, rec.Header().Get(`Content-Length`), `23`)
+	eq(t, rec.Header().Get(`Vary`), ``)
+	eq(t, rec.Body.String(), `<div>hello world!</div>`)
+	eq(t, rec.Header().Get(`ETag`), etag([]byte(`<div>hello world!</div>`), false))
+}
+
+func TestHandler_HEAD(t *testing.T) {
+	rec := serve(Handle(Str(`<div></div>`)), httptest.NewRequest(http.MethodHead, `/`, nil))
+
+	eq(t, rec.Code, http.StatusOK)
+	eq(t, rec.Header().Get(`Content-Length`), `11`)
+	eq(t, rec.Body.Len(), 0)
+}
+
+func TestHandler_Type(t *testing.T) {
+	rec := serve(Handle(Str(`<?xml version="1.0"?><feed></feed>`)), httptest.NewRequest(http.MethodGet, `/`, nil))
+	eq(t, rec.Header().Get(`Content-Type`), TypeXml)
+
+	han := Handle(Str(`<svg></svg>`))
+	han.Type = `image/svg+xml`
+	rec = serve(han, httptest.NewRequest(http.MethodGet, `/`, nil))
+	eq(t, rec.Header().Get(`Content-Type`), `image/svg+xml`)
+}
+
+func TestHandleFunc(t *testing.T) {
+	han := HandleFunc(func(req *http.Request) Ren {
+		return E(`p`, nil, req.URL.Query().Get(`name`))
+	})
+
+	rec := serve(han, httptest.NewRequest(http.MethodGet, `/?name=<one>`, nil))
+	eq(t, rec.Body.String(), `<p>&lt;one&gt;</p>`)
+
+	rec = serve(HandleFunc(func(*http.Request) Ren { return nil }), httptest.NewRequest(http.MethodGet, `/`, nil))
+	eq(t, rec.Code, http.StatusOK)
+	eq(t, rec.Body.Len(), 0)
+}
+
+func TestHandler_If_None_Match(t *testing.T) {
+	han := Handle(E(`div`, nil, `hello world!`))
+	tag := etag([]byte(`<div>hello world!</div>`), false)
+
+	test := func(method, header string, code int) {
+		t.Helper()
+		req := httptest.NewRequest(method, `/`, nil)
+		req.Header.Set(`If-None-Match`, header)
+		eq(t, serve(han, req).Code, code)
+	}
+
+	test(http.MethodGet, tag, http.StatusNotModified)
+	test(http.MethodHead, tag, http.StatusNotModified)
+	test(http.MethodGet, `W/`+tag, http.StatusNotModified)
+	test(http.MethodGet, `"one", `+tag, http.StatusNotModified)
+	test(http.MethodGet, `*`, http.StatusNotModified)
+	test(http.MethodGet, `"one"`, http.StatusOK)
+	test(http.MethodGet, ``, http.StatusOK)
+	test(http.MethodPost, tag, http.StatusOK)
+
+	req := httptest.NewRequest(http.MethodGet, `/`, nil)
+	req.Header.Set(`If-None-Match`, tag)
+	rec := serve(han, req)
+	eq(t, rec.Header().Get(`ETag`), tag)
+	eq(t, rec.Body.Len(), 0)
+}
+
+func TestHandler_Gzip(t *testing.T) {
+	han := Handle(E(`div`, nil, `hello world!`))
+	han.Gzip = true
+
+	req := httptest.NewRequest(http.MethodGet, `/`, nil)
+	rec := serve(han, req)
+	eq(t, rec.Header().Get(`Content-Encoding`), ``)
+	eq(t, rec.Header().Get(`Vary`), `Accept-Encoding`)
+	eq(t, rec.Body.String(), `<div>hello world!</div>`)
+
+	req.Header.Set(`Accept-Encoding`, `deflate, gzip;q=0.5`)
+	rec = serve(han, req)
+	eq(t, rec.Header().Get(`Content-Encoding`), `gzip`)
+	eq(t, rec.Header().Get(`Content-Type`), TypeHtml)
+	eq(t, rec.Header().Get(`ETag`), etag([]byte(`<div>hello world!</div>`), true))
+	eq(t, gunzip(rec.Body.Bytes()), `<div>hello world!</div>`)
+
+	req.Header.Set(`If-None-Match`, rec.Header().Get(`ETag`))
+	eq(t, serve(han, req).Code, http.StatusNotModified)
+}
+
+func Test_acceptsGzip(t *testing.T) {
+	eq(t, acceptsGzip(``), false)
+	eq(t, acceptsGzip(`deflate, br`), false)
+	eq(t, acceptsGzip(`gzip`), true)
+	eq(t, acceptsGzip(`br, gzip`), true)
+	eq(t, acceptsGzip(`gzip;q=0`), false)
+	eq(t, acceptsGzip(`gzip; q=0.0`), false)
+	eq(t, acceptsGzip(`gzip;q=0.1`), true)
+	eq(t, acceptsGzip(`*`), true)
+	eq(t, acceptsGzip(`*;q=0`), false)
+	eq(t, acceptsGzip(`*, gzip;q=0`), false)
+	eq(t, acceptsGzip(`*;q=0, gzip`), true)
+}
+
+func serve(han http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	han.ServeHTTP(rec, req)
+	return rec
+}
+
+func gunzip(src []byte) string {
+	read, err := gzip.NewReader(bytes.NewReader(src))
+	must(err)
+	out, err := io.ReadAll(read)
+	must(err)
+	return string(out)
+}
+
+func TestHandler_error(t *testing.T) {
+	fail := func(bui *Bui) error {
+		bui.T(`partial`)
+		return io.ErrUnexpectedEOF
+	}
+
+	han := Handle(E(`div`, nil, fail))
+	rec := serve(han, httptest.NewRequest(http.MethodGet, `/`, nil))
+
+	eq(t, rec.Code, http.StatusInternalServerError)
+	eq(t, rec.Header().Get(`ETag`), ``)
+	eq(t, rec.Body.String(), "Internal Server Error\n")
+
+	var caught error
+	han.OnErr = func(rew http.ResponseWriter, _ *http.Request, err error) {
+		caught = err
+		rew.WriteHeader(http.StatusTeapot)
+	}
+	rec = serve(han, httptest.NewRequest(http.MethodGet, `/`, nil))
+
+	eq(t, rec.Code, http.StatusTeapot)
+	eq(t, caught.Error(), `[gax] render error at "div": unexpected EOF`)
+}
+
+func TestHandler_error_panic(t *testing.T) {
+	var caught error
+	han := Handle(E(`script`, nil, `"</script>"`))
+	han.OnErr = func(rew http.ResponseWriter, _ *http.Request, err error) {
+		caught = err
+		rew.WriteHeader(http.StatusTeapot)
+	}
+	rec := serve(han, httptest.NewRequest(http.MethodGet, `/`, nil))
+
+	eq(t, rec.Code, http.StatusTeapot)
+	eq(t, caught, error(RawError{Tag: `script`, Text: `</script`, Path: []string{`script`}}))
+}
+
+func TestHandler_Context(t *testing.T) {
+	han := HandleFunc(func(*http.Request) Ren {
+		return Frag{func(bui *Bui) { bui.C(bui.Context().Value(ctxKey{})) }}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, `/`, nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxKey{}, `one`))
+	eq(t, serve(han, req).Body.String(), `one`)
+}
@@ -1,7 +1,6 @@
 package gax
 
 import (
-	"fmt"
 	r "reflect"
 	"strconv"
 	"strings"
@@ -25,38 +24,107 @@ func (self stringSet) Add(val string)      { self[val] = struct{}{} }
 func (self stringSet) Del(val string)      { delete(self, val) }
 
 /*
-Extremely permissive. Should prevent weird gotchas without interfering with
-non-ASCII XML.
-
-Reference for HTML:
-
-	https://www.w3.org/TR/html52/syntax.html#tag-name
-	https://www.w3.org/TR/html52/infrastructure.html#alphanumeric-ascii-characters
-
-Also see for attrs, unused:
-
-	https://www.w3.org/TR/html52/syntax.html#elements-attributes
+Validates the tag per `Names` of the current dialect, which is permissive by
+default. The permissive rules should prevent weird gotchas without interfering
+with non-ASCII XML. Panics with `NameError`.
 */
-func validTag(val string) {
-	if invalidTagOrAttr(val) {
-		panic(fmt.Errorf(`[gax] invalid tag name %q`, val))
+func validTag(st *state, val string) {
+	names := st.getDialect().getNames()
+	if !names.validTag(val) {
+		panic(NameError{Tag: val, Path: st.path(), Names: names})
 	}
 }
 
 /*
-Extremely permissive. Intended only to prevent weird gotchas without interfering
-with non-ASCII XML.
+Validates the attribute name per `Names` of the current dialect, like
+`validTag`. When called during `Bui.Begin`, the current element is the one
+with this attribute.
 */
-func validAttr(val string) {
-	if invalidTagOrAttr(val) {
-		panic(fmt.Errorf(`[gax] invalid attribute name %q`, val))
+func validAttr(st *state, val string) {
+	names := st.getDialect().getNames()
+	if names.validAttr(val) {
+		return
 	}
+
+	err := NameError{Attr: val, Names: names}
+	if path := st.path(); len(path) > 0 {
+		err.Tag = path[len(path)-1]
+		err.Path = path[:len(path)-1]
+	}
+	panic(err)
 }
 
 func invalidTagOrAttr(val string) bool {
 	return strings.ContainsAny(val, " \t\n\r\v<>\"=")
 }
 
+func isRaw(tag string) bool {
+	return Raw.Has(tag) || hasUpper(tag) && Raw.Has(strings.ToLower(tag))
+}
+
+/*
+Panics if the content of a raw text element contains the closing tag of that
+element, in any case, followed by whitespace, "/", ">" or the end of the
+content, or, in `script`, the start of a comment followed by
+"<script" without the end of the comment, which causes the closing tag to be
+ignored. Reference:
+
+	https://html.spec.whatwg.org/multipage/scripting.html#restrictions-for-contents-of-script-elements
+*/
+func validRaw(st *state, tag string, val []byte) {
+	if bad := rawInvalid(tag, bytesString(val)); bad != `` {
+		panic(RawError{Tag: tag, Text: bad, Path: st.path()})
+	}
+}
+
+// Returns the sequence which makes the raw text invalid, or "". See `validRaw`.
+func rawInvalid(tag, val string) string {
+	script := strings.EqualFold(tag, `script`)
+
+	for ind := 0; ind < len(val); ind++ {
+		if val[ind] != '<' {
+			continue
+		}
+		rest := val[ind:]
+
+		if len(rest) >= len(tag)+2 && rest[1] == '/' && isEndTagFor(rest, tag) {
+			return rest[:2+len(tag)]
+		}
+		if script && strings.HasPrefix(rest, `<!--`) &&
+			!strings.Contains(rest[2:], `-->`) && hasScriptStart(rest) {
+			return `<!--`
+		}
+	}
+	return ``
+}
+
+// True if the input contains "<script" followed by whitespace, "/" or ">".
+func hasScriptStart(val string) bool {
+	for {
+		ind := strings.IndexByte(val, '<')
+		if ind < 0 {
+			return false
+		}
+		val = val[ind+1:]
+		if len(val) > len(`script`) && strings.EqualFold(val[:len(`script`)], `script`) &&
+			isTagDelim(val[len(`script`)]) {
+			return true
+		}
+	}
+}
+
+/*
+Reference:
+
+	https://html.spec.whatwg.org/multipage/syntax.html#comments
+*/
+func validComment(val string) {
+	if strings.HasPrefix(val, `>`) || strings.HasPrefix(val, `->`) ||
+		strings.Contains(val, `-->`) || strings.Contains(val, `--!>`) {
+		panic(CommentError{val})
+	}
+}
+
 func isNil(val any) bool {
 	return val == nil || isRvalNil(r.ValueOf(val))
 }
@@ -104,3 +172,25 @@ func grow(prev []byte, size int) []byte {
 }
 
 func iter(count int) []struct{} { return make([]struct{}, count) }
+
+func hasUpper(val string) bool {
+	for ind := 0; ind < len(val); ind++ {
+		if val[ind] >= 'A' && val[ind] <= 'Z' {
+			return true
+		}
+	}
+	return false
+}
+
+func isAsciiLetter(val byte) bool {
+	return (val >= 'a' && val <= 'z') || (val >= 'A' && val <= 'Z')
+}
+
+func isAsciiDigit(val byte) bool { return val >= '0' && val <= '9' }
+
+func lowerAscii(val byte) byte {
+	if val >= 'A' && val <= 'Z' {
+		return val + ('a' - 'A')
+	}
+	return val
+}
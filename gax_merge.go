@@ -0,0 +1,140 @@
+package gax
+
+import "strings"
+
+/*
+Strategy for combining two attributes with the same name, used by
+`Attrs.Merge`. Takes the previous and the next attribute, and returns the
+combined attribute.
+*/
+type AttrMerge func(prev, next Attr) Attr
+
+/*
+Strategies used by `Attrs.Merge` for specific attributes. Attributes not listed
+here, including event handlers such as "onclick", use `MergeLast`. Can be
+extended, for example with `AttrMerges["rel"] = MergeTokens`. Like `Bool`,
+this is global, and modifying it during concurrent renders is a data race.
+*/
+var AttrMerges = map[string]AttrMerge{
+	`class`: MergeTokens,
+	`style`: MergeStyle,
+}
+
+// Strategy for `AttrMerges` where the next attribute replaces the previous.
+func MergeLast(_, next Attr) Attr { return next }
+
+/*
+Strategy for `AttrMerges` for space-separated token lists such as "class" or
+"rel". Appends the tokens of the next value to the tokens of the previous
+value, skipping duplicates. Normalizes whitespace.
+*/
+func MergeTokens(prev, next Attr) Attr {
+	var out []string
+	for _, src := range [2]string{prev.Value(), next.Value()} {
+		for _, val := range strings.Fields(src) {
+			if !hasString(out, val) {
+				out = append(out, val)
+			}
+		}
+	}
+	return prev.Set(strings.Join(out, ` `))
+}
+
+/*
+Strategy for `AttrMerges` for inline CSS in the "style" attribute. Declarations
+in the next value replace declarations of the same property in the previous
+value, which is how CSS would apply them anyway, and the result has no
+redundant declarations. Property names are compared case-insensitively,
+except for custom properties such as "--one".
+*/
+func MergeStyle(prev, next Attr) Attr {
+	decls := styleDecls(next.Value())
+	var out []string
+
+	for _, val := range styleDecls(prev.Value()) {
+		if !hasStyleProp(decls, styleProp(val)) {
+			out = append(out, val)
+		}
+	}
+	return prev.Set(strings.Join(append(out, decls...), `; `))
+}
+
+/*
+Combines two attributes with the same name. Attributes created via `Flag` are
+never combined with anything, and the last one wins.
+*/
+func mergeAttr(prev, next Attr) Attr {
+	if (prev.mark()|next.mark())&markFlag != 0 {
+		return next
+	}
+	if fun := AttrMerges[next.Name()]; fun != nil {
+		return fun(prev, next)
+	}
+	return MergeLast(prev, next)
+}
+
+/*
+Splits inline CSS into trimmed non-empty declarations. Semicolons in quotes,
+parentheses and comments don't split declarations.
+*/
+func styleDecls(src string) []string {
+	var out []string
+	var quote byte
+	var depth int
+	var start int
+
+	add := func(end int) {
+		if val := strings.TrimSpace(src[start:end]); val != `` {
+			out = append(out, val)
+		}
+	}
+
+	for ind := 0; ind < len(src); ind++ {
+		char := src[ind]
+
+		switch {
+		case char == '\\':
+			ind++
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '"' || char == '\'':
+			quote = char
+		case char == '/' && strings.HasPrefix(src[ind:], `/*`):
+			end := strings.Index(src[ind+2:], `*/`)
+			if end < 0 {
+				ind = len(src)
+			} else {
+				ind += end + 3
+			}
+		case char == '(':
+			depth++
+		case char == ')' && depth > 0:
+			depth--
+		case char == ';' && depth == 0:
+			add(ind)
+			start = ind + 1
+		}
+	}
+
+	if start < len(src) {
+		add(len(src))
+	}
+	return out
+}
+
+// Normalized property name of a CSS declaration, see `styleKey`.
+func styleProp(decl string) string {
+	key, _, _ := strings.Cut(decl, `:`)
+	return styleKey(strings.TrimSpace(key))
+}
+
+func hasStyleProp(decls []string, key string) bool {
+	for _, val := range decls {
+		if styleProp(val) == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package gax
+
+import "testing"
+
+func TestMergeTokens(t *testing.T) {
+	test := func(prev, next, exp string) {
+		t.Helper()
+		eq(t, MergeTokens(Attr{`class`, prev}, Attr{`class`, next}), Attr{`class`, exp})
+	}
+
+	test(``, ``, ``)
+	test(`one`, ``, `one`)
+	test(``, `one`, `one`)
+	test(`one`, `one`, `one`)
+	test("one\ttwo", ` three one `, `one two three`)
+	test(`one one`, `two two`, `one two`)
+}
+
+func TestMergeStyle(t *testing.T) {
+	test := func(prev, next, exp string) {
+		t.Helper()
+		eq(t, MergeStyle(Attr{`style`, prev}, Attr{`style`, next}), Attr{`style`, exp})
+	}
+
+	test(``, ``, ``)
+	test(`color: red`, ``, `color: red`)
+	test(``, `color: red;`, `color: red`)
+	test(`color: red; margin: 0;`, `color: blue`, `margin: 0; color: blue`)
+	test(`Color: red`, `color: blue`, `color: blue`)
+	test(`--one: red`, `--ONE: blue`, `--one: red; --ONE: blue`)
+	test(`background: url("a;b")`, `color: red`, `background: url("a;b"); color: red`)
+	test(`content: ";"; color: red`, `content: 'a;b'`, `color: red; content: 'a;b'`)
+	test(`color: red /* ; */`, `margin: 0`, `color: red /* ; */; margin: 0`)
+}
+
+func TestAttrMerges(t *testing.T) {
+	prev := AttrMerges[`rel`]
+	AttrMerges[`rel`] = MergeTokens
+	defer func() { AttrMerges[`rel`] = prev }()
+
+	eq(t, AP(`rel`, `noopener`).Merge(AP(`rel`, `noreferrer`)), AP(`rel`, `noopener noreferrer`))
+}
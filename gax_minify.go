@@ -0,0 +1,215 @@
+package gax
+
+/*
+Output which may be removed if followed by a suitable start or end tag, see
+`Ctx.Minify`. Either an optional end tag, or whitespace-only text between
+blocks, indicated by an empty `.tag`. Tails are always at the end of the
+buffer, ordered by position.
+*/
+type tail struct {
+	start int
+	end   mark
+	tag   string
+}
+
+// Position in the output of a render, which remains valid until a flush.
+type mark struct {
+	pos     int
+	flushes int
+}
+
+func (self *state) mark(bui *Bui) mark {
+	out := mark{pos: len(*bui)}
+	if self.stream != nil {
+		out.flushes = self.stream.flushes
+	}
+	return out
+}
+
+func (self *state) tail(bui *Bui, start int, tag string) {
+	end := self.mark(bui)
+	if len(self.tails) > 0 {
+		prev := self.tails[len(self.tails)-1]
+		if prev.end.flushes != end.flushes || prev.end.pos != start {
+			self.tails = self.tails[:0]
+		}
+	}
+	self.tails = append(self.tails, tail{start: start, end: end, tag: tag})
+}
+
+/*
+Called before writing a start tag, or before writing the end tag of the parent
+of the tails, when `end` is true. Removes tails which are allowed to be omitted
+before the given tag, from last to first.
+*/
+func (self *state) retract(bui *Bui, tag string, end bool) {
+	cur := self.mark(bui)
+
+	for ind := len(self.tails) - 1; ind >= 0; ind-- {
+		val := self.tails[ind]
+		if val.end != cur || !val.omit(tag, end) {
+			break
+		}
+		*bui = (*bui)[:val.start]
+		cur.pos = val.start
+	}
+	self.tails = self.tails[:0]
+}
+
+func (self tail) omit(tag string, end bool) bool {
+	if self.tag == `` {
+		return end || Block.Has(tag)
+	}
+	if end {
+		return minifyEndParent[self.tag].Has(tag)
+	}
+	return minifyEndNext[self.tag].Has(tag)
+}
+
+// Called when a render is done. Omits the end tag of `html`, if possible.
+func (self *state) finish(bui *Bui) {
+	if self.minify() {
+		self.retract(bui, ``, true)
+	}
+}
+
+/*
+Called by `Bui.End` after writing the end tag starting at `start`, with the
+dialect of the element.
+*/
+func (self *state) ended(bui *Bui, tag string, start int, dia *Dialect) {
+	if !self.minify() || dia.foreign() {
+		return
+	}
+	if _, ok := minifyEndParent[tag]; ok && self.raw == 0 {
+		self.tail(bui, start, tag)
+	}
+	if Block.Has(tag) {
+		self.edge = self.mark(bui)
+	}
+}
+
+/*
+Called after writing text starting at `start`. Whitespace-only text directly
+between blocks, or between a block and the start or end of its block parent,
+doesn't affect rendering, and is omitted.
+*/
+func (self *state) text(bui *Bui, start int) {
+	if !self.minify() || self.dialect.foreign() || self.raw > 0 || !isSpaceOnly((*bui)[start:]) {
+		return
+	}
+
+	top := self.top()
+	if top == nil || top.pre || !Block.Has(top.tag) {
+		return
+	}
+
+	if self.edge == (mark{start, self.mark(bui).flushes}) {
+		self.tail(bui, start, ``)
+		return
+	}
+
+	if len(self.tails) > 0 {
+		prev := &self.tails[len(self.tails)-1]
+		if prev.tag == `` && prev.end.pos == start {
+			prev.end = self.mark(bui)
+		}
+	}
+}
+
+/*
+Optional end tags which may be omitted when followed by one of the given start
+tags. Reference:
+
+	https://html.spec.whatwg.org/multipage/syntax.html#optional-tags
+*/
+var minifyEndNext = map[string]stringSet{
+	`body`:     nil,
+	`caption`:  newStringSet(`colgroup`, `tbody`, `tfoot`, `thead`, `tr`),
+	`colgroup`: newStringSet(`colgroup`, `tbody`, `tfoot`, `thead`, `tr`),
+	`dd`:       newStringSet(`dd`, `dt`),
+	`dt`:       newStringSet(`dd`, `dt`),
+	`head`:     newStringSet(`body`),
+	`html`:     nil,
+	`li`:       newStringSet(`li`),
+	`optgroup`: newStringSet(`hr`, `optgroup`),
+	`option`:   newStringSet(`hr`, `optgroup`, `option`),
+	`p`: newStringSet(
+		`address`, `article`, `aside`, `blockquote`, `details`, `dialog`, `div`,
+		`dl`, `fieldset`, `figcaption`, `figure`, `footer`, `form`, `h1`, `h2`,
+		`h3`, `h4`, `h5`, `h6`, `header`, `hgroup`, `hr`, `main`, `menu`, `nav`,
+		`ol`, `p`, `pre`, `search`, `section`, `table`, `ul`,
+	),
+	`rp`:    newStringSet(`rp`, `rt`),
+	`rt`:    newStringSet(`rp`, `rt`),
+	`tbody`: newStringSet(`tbody`, `tfoot`),
+	`td`:    newStringSet(`td`, `th`),
+	`tfoot`: nil,
+	`th`:    newStringSet(`td`, `th`),
+	`thead`: newStringSet(`tbody`, `tfoot`),
+	`tr`:    newStringSet(`tr`),
+}
+
+/*
+Optional end tags which may be omitted at the end of one of the given parents.
+The spec allows more parents for some elements, but for non-conforming nesting,
+parsers may ignore the end tag of the parent, so we stick to parents whose end
+tags reliably close these elements. An empty string stands for the end of the
+document.
+*/
+var minifyEndParent = map[string]stringSet{
+	`body`:     newStringSet(`html`),
+	`caption`:  newStringSet(`table`),
+	`colgroup`: newStringSet(`table`),
+	`dd`:       newStringSet(`div`, `dl`),
+	`dt`:       nil,
+	`head`:     newStringSet(`html`),
+	`html`:     newStringSet(``),
+	`li`:       newStringSet(`menu`, `ol`, `ul`),
+	`optgroup`: newStringSet(`select`),
+	`option`:   newStringSet(`datalist`, `optgroup`, `select`),
+	`p`: newStringSet(
+		`address`, `article`, `aside`, `blockquote`, `body`, `button`, `dd`,
+		`details`, `dialog`, `div`, `fieldset`, `figcaption`, `figure`,
+		`footer`, `form`, `header`, `li`, `main`, `nav`, `search`, `section`,
+		`summary`, `td`, `th`,
+	),
+	`rp`:    newStringSet(`ruby`, `rtc`),
+	`rt`:    newStringSet(`ruby`, `rtc`),
+	`tbody`: newStringSet(`table`),
+	`td`:    newStringSet(`tr`),
+	`tfoot`: newStringSet(`table`),
+	`th`:    newStringSet(`tr`),
+	`thead`: nil,
+	`tr`:    newStringSet(`table`, `tbody`, `tfoot`, `thead`),
+}
+
+/*
+True if the attribute value may be written without quotes. Reference:
+
+	https://html.spec.whatwg.org/multipage/syntax.html#unquoted
+
+Values ending with "/" keep the quotes, since the following ">" would make the
+tag self-closing, which affects foreign content such as SVG.
+*/
+func isUnquotable(val string) bool {
+	if val == `` || val[len(val)-1] == '/' {
+		return false
+	}
+	for ind := 0; ind < len(val); ind++ {
+		switch val[ind] {
+		case ' ', '\t', '\n', '\f', '\r', '"', '\'', '=', '<', '>', '`':
+			return false
+		}
+	}
+	return true
+}
+
+func isSpaceOnly(val []byte) bool {
+	for _, char := range val {
+		if !isSpace(char) {
+			return false
+		}
+	}
+	return len(val) > 0
+}
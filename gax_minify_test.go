@@ -0,0 +1,82 @@
+package gax
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCtx_Minify(t *testing.T) {
+	ctx := Ctx{Minify: true}
+
+	test := func(exp string, vals ...any) {
+		t.Helper()
+		eqs(t, ctx.F(vals...), exp)
+	}
+
+	test(`<p>one<p>two</p>`, E(`p`, nil, `one`), E(`p`, nil, `two`))
+	test(`<p>one</p>two`, E(`p`, nil, `one`), `two`)
+	test(`<p>one</p><span>two</span>`, E(`p`, nil, `one`), E(`span`, nil, `two`))
+	test(`<div><p>one</div>`, E(`div`, nil, E(`p`, nil, `one`)))
+	test(`<a><p>one</p></a>`, E(`a`, nil, E(`p`, nil, `one`)))
+	test(`<span><p>one</p></span>`, E(`span`, nil, E(`p`, nil, `one`)))
+	test(`<ul><li>one<li>two</ul>`, E(`ul`, nil, E(`li`, nil, `one`), E(`li`, nil, `two`)))
+	test(`<dl><dt>one<dd>two<dt>three</dt></dl>`, E(`dl`, nil, E(`dt`, nil, `one`), E(`dd`, nil, `two`), E(`dt`, nil, `three`)))
+	test(
+		`<table><thead><tr><th>one<tbody><tr><td>two<td>three<tr><td>four</table>`,
+		E(`table`, nil,
+			E(`thead`, nil, E(`tr`, nil, E(`th`, nil, `one`))),
+			E(`tbody`, nil,
+				E(`tr`, nil, E(`td`, nil, `two`), E(`td`, nil, `three`)),
+				E(`tr`, nil, E(`td`, nil, `four`)),
+			),
+		),
+	)
+	test(`<select><option>one<option>two</select>`, E(`select`, nil, E(`option`, nil, `one`), E(`option`, nil, `two`)))
+	test(
+		`<!doctype html><html><head><title>one</title><body><p>two`,
+		Str(Doctype), E(`html`, nil, E(`head`, nil, E(`title`, nil, `one`)), E(`body`, nil, E(`p`, nil, `two`))),
+	)
+	test(`<p>one</p><!-- two -->`, E(`p`, nil, `one`), Comment(` two `))
+
+	test(
+		`<input type=text value="one two" name=a&amp;b checked disabled data-one title="&quot;">`,
+		E(`input`, AP(`type`, `text`, `value`, `one two`, `name`, `a&b`, `checked`, ``, `disabled`, `true`, `readonly`, `false`, `data-one`, ``, `title`, `"`)),
+	)
+	test(`<a href=/one>two</a>`, E(`a`, AP(`href`, `/one`), `two`))
+	test(`<a href="/one/">two</a>`, E(`a`, AP(`href`, `/one/`), `two`))
+
+	test(
+		"<div><p>one <b>two</b> three<p>four</div>",
+		E(`div`, nil, "\n  ", E(`p`, nil, `one `, E(`b`, nil, `two`), ` three`), "\n  ", E(`p`, nil, `four`), "\n"),
+	)
+	test("<div><b>one</b> <i>two</i></div>", E(`div`, nil, E(`b`, nil, `one`), ` `, E(`i`, nil, `two`)))
+	test("<div> <span>one</span></div>", E(`div`, nil, ` `, E(`span`, nil, `one`)))
+	test("<pre> <div> </div> </pre>", E(`pre`, nil, ` `, E(`div`, nil, ` `), ` `))
+	test("<script> </script>", E(`script`, nil, ` `))
+
+	ctx.Dialect = DialectHtml
+	test(
+		`<svg viewBox="0 0 1 1"><path d="M0"/><circle r="1"/></svg><p class=one></p>`,
+		E(`svg`, AP(`viewBox`, `0 0 1 1`), E(`path`, AP(`d`, `M0`)), E(`circle`, AP(`r`, `1`))),
+		E(`p`, AP(`class`, `one`)),
+	)
+}
+
+func TestCtx_Minify_roundtrip(t *testing.T) {
+	src := renderDynamic(mockDat).String()
+	min := (&Ctx{Minify: true}).F(ParseHtml(src)).String()
+
+	if len(min) >= len(src) {
+		t.Fatalf(`expected minified output to be smaller, got %v >= %v`, len(min), len(src))
+	}
+	eq(t, ParseHtml(min).String(), src)
+}
+
+func TestCtx_Minify_Stream(t *testing.T) {
+	var buf strings.Builder
+	out := Stream{Wri: &buf, Ctx: &Ctx{Minify: true}}
+
+	out.E(`html`, nil, E(`body`, nil, E(`ul`, nil, E(`li`, nil, `one`), E(`li`, nil, `two`))))
+	eq(t, out.Close(), nil)
+	eq(t, buf.String(), `<html><body><ul><li>one<li>two</ul>`)
+}
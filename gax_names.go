@@ -0,0 +1,237 @@
+package gax
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+Rules for validating element and attribute names, selected via
+`DialectConf.Names`. Invalid names cause a panic with `NameError`. The zero
+value is `NamesPermissive`.
+*/
+type Names byte
+
+const (
+	/*
+		Rejects only names which would break the markup: names containing
+		whitespace, quotes, angle brackets or "=". The default, and the only rule
+		used when no dialect is selected.
+	*/
+	NamesPermissive Names = iota
+
+	/*
+		Names valid in HTML syntax. Element names must be ASCII alphanumeric,
+		starting with a letter, or valid custom element names: lowercase,
+		starting with a letter, and containing a hyphen. Attribute names must
+		not contain controls, whitespace, quotes, "/", "<", ">", "=", or
+		Unicode noncharacters. Reference:
+
+			https://html.spec.whatwg.org/multipage/syntax.html#syntax-tag-name
+			https://html.spec.whatwg.org/multipage/syntax.html#attributes-2
+			https://html.spec.whatwg.org/multipage/custom-elements.html#valid-custom-element-name
+	*/
+	NamesHtml
+
+	/*
+		XML qualified names: an XML NCName, optionally preceded by a namespace
+		prefix which is also an NCName, such as "xlink:href". Applies to both
+		elements and attributes. Reference:
+
+			https://www.w3.org/TR/xml-names/#ns-qualnames
+	*/
+	NamesXml
+)
+
+// Implement `fmt.Stringer` for debug purposes.
+func (self Names) String() string {
+	switch self {
+	case NamesPermissive:
+		return `permissive`
+	case NamesHtml:
+		return `html`
+	case NamesXml:
+		return `xml`
+	default:
+		return fmt.Sprintf(`Names(%d)`, byte(self))
+	}
+}
+
+/*
+Error caused by an invalid element or attribute name, see `Names`. Rendering
+panics with this error.
+*/
+type NameError struct {
+	// Name of the invalid element, or of the element with the invalid
+	// attribute, if known.
+	Tag string
+
+	// Name of the invalid attribute. Empty if the element name is invalid.
+	Attr string
+
+	// Names of the ancestors of the element, outermost first, if known.
+	Path []string
+
+	// Rules which were violated.
+	Names Names
+}
+
+// Implement `error`.
+func (self NameError) Error() string {
+	var buf strings.Builder
+	if self.Attr != `` {
+		fmt.Fprintf(&buf, `[gax] invalid attribute name %q`, self.Attr)
+		if self.Tag != `` {
+			fmt.Fprintf(&buf, ` in element %q`, self.Tag)
+		}
+	} else {
+		fmt.Fprintf(&buf, `[gax] invalid tag name %q`, self.Tag)
+	}
+
+	if self.Names != NamesPermissive {
+		fmt.Fprintf(&buf, ` (%v)`, self.Names)
+	}
+	if len(self.Path) > 0 {
+		fmt.Fprintf(&buf, ` at %q`, strings.Join(self.Path, ` > `))
+	}
+	return buf.String()
+}
+
+func (self Names) validTag(val string) bool {
+	switch self {
+	case NamesHtml:
+		return isHtmlTag(val)
+	case NamesXml:
+		return isXmlQname(val)
+	default:
+		return !invalidTagOrAttr(val)
+	}
+}
+
+func (self Names) validAttr(val string) bool {
+	switch self {
+	case NamesHtml:
+		return isHtmlAttr(val)
+	case NamesXml:
+		return isXmlQname(val)
+	default:
+		return !invalidTagOrAttr(val)
+	}
+}
+
+func isHtmlTag(val string) bool {
+	if val == `` || !isAsciiLetter(val[0]) {
+		return false
+	}
+
+	alnum := true
+	for ind := 0; ind < len(val); ind++ {
+		if !isAsciiLetter(val[ind]) && !isAsciiDigit(val[ind]) {
+			alnum = false
+			break
+		}
+	}
+	return alnum || isCustomTag(val)
+}
+
+func isCustomTag(val string) bool {
+	if val == `` || val[0] < 'a' || val[0] > 'z' || !strings.Contains(val, `-`) {
+		return false
+	}
+
+	for _, char := range val {
+		if !isPcenChar(char) {
+			return false
+		}
+	}
+	return utf8.ValidString(val)
+}
+
+func isPcenChar(val rune) bool {
+	return val == '-' || val == '.' || val == '_' || val == 0xb7 ||
+		val >= '0' && val <= '9' ||
+		val >= 'a' && val <= 'z' ||
+		val >= 0xc0 && val <= 0xd6 ||
+		val >= 0xd8 && val <= 0xf6 ||
+		val >= 0xf8 && val <= 0x37d ||
+		val >= 0x37f && val <= 0x1fff ||
+		val >= 0x200c && val <= 0x200d ||
+		val >= 0x203f && val <= 0x2040 ||
+		val >= 0x2070 && val <= 0x218f ||
+		val >= 0x2c00 && val <= 0x2fef ||
+		val >= 0x3001 && val <= 0xd7ff ||
+		val >= 0xf900 && val <= 0xfdcf ||
+		val >= 0xfdf0 && val <= 0xfffd ||
+		val >= 0x10000 && val <= 0xeffff
+}
+
+func isHtmlAttr(val string) bool {
+	if val == `` || !utf8.ValidString(val) {
+		return false
+	}
+
+	for _, char := range val {
+		switch {
+		case char <= 0x20, char >= 0x7f && char <= 0x9f:
+			return false
+		case char == '"', char == '\'', char == '/', char == '<', char == '>', char == '=':
+			return false
+		case isNonchar(char):
+			return false
+		}
+	}
+	return true
+}
+
+func isNonchar(val rune) bool {
+	return val >= 0xfdd0 && val <= 0xfdef || val&0xfffe == 0xfffe
+}
+
+func isXmlQname(val string) bool {
+	prefix, local, ok := strings.Cut(val, `:`)
+	if !ok {
+		return isXmlNcname(val)
+	}
+	return isXmlNcname(prefix) && isXmlNcname(local)
+}
+
+func isXmlNcname(val string) bool {
+	if val == `` || !utf8.ValidString(val) {
+		return false
+	}
+
+	for ind, char := range val {
+		if char == ':' || !isXmlNameStart(char) && (ind == 0 || !isXmlNameChar(char)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Reference: https://www.w3.org/TR/xml/#NT-NameStartChar
+func isXmlNameStart(val rune) bool {
+	return val == ':' || val == '_' ||
+		val >= 'A' && val <= 'Z' ||
+		val >= 'a' && val <= 'z' ||
+		val >= 0xc0 && val <= 0xd6 ||
+		val >= 0xd8 && val <= 0xf6 ||
+		val >= 0xf8 && val <= 0x2ff ||
+		val >= 0x370 && val <= 0x37d ||
+		val >= 0x37f && val <= 0x1fff ||
+		val >= 0x200c && val <= 0x200d ||
+		val >= 0x2070 && val <= 0x218f ||
+		val >= 0x2c00 && val <= 0x2fef ||
+		val >= 0x3001 && val <= 0xd7ff ||
+		val >= 0xf900 && val <= 0xfdcf ||
+		val >= 0xfdf0 && val <= 0xfffd ||
+		val >= 0x10000 && val <= 0xeffff
+}
+
+// Reference: https://www.w3.org/TR/xml/#NT-NameChar
+func isXmlNameChar(val rune) bool {
+	return isXmlNameStart(val) || val == '-' || val == '.' || val == 0xb7 ||
+		val >= '0' && val <= '9' ||
+		val >= 0x300 && val <= 0x36f ||
+		val >= 0x203f && val <= 0x2040
+}
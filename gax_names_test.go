@@ -0,0 +1,94 @@
+package gax
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNames(t *testing.T) {
+	test := func(names Names, tag, attr bool, val string) {
+		t.Helper()
+		eq(t, names.validTag(val), tag)
+		eq(t, names.validAttr(val), attr)
+	}
+
+	test(NamesPermissive, true, true, `a/b`)
+	test(NamesPermissive, true, true, `onclick'x`)
+	test(NamesPermissive, false, false, `a b`)
+
+	test(NamesHtml, true, true, `div`)
+	test(NamesHtml, true, true, `H1`)
+	test(NamesHtml, true, true, `my-elem`)
+	test(NamesHtml, true, true, `math-α`)
+	test(NamesHtml, false, true, `My-Elem`)
+	test(NamesHtml, false, true, `my_elem`)
+	test(NamesHtml, false, true, `1a`)
+	test(NamesHtml, false, true, `@click`)
+	test(NamesHtml, true, true, `data-one.two`)
+	test(NamesHtml, false, false, `a/b`)
+	test(NamesHtml, false, false, `onclick'x`)
+	test(NamesHtml, false, false, "one\x00")
+	test(NamesHtml, false, false, "one\u0085")
+	test(NamesHtml, false, false, "one\ufdd0")
+	test(NamesHtml, false, false, ``)
+
+	test(NamesXml, true, true, `svg`)
+	test(NamesXml, true, true, `xlink:href`)
+	test(NamesXml, true, true, `_one.two-3`)
+	test(NamesXml, true, true, `élément`)
+	test(NamesXml, false, false, `one:two:three`)
+	test(NamesXml, false, false, `:one`)
+	test(NamesXml, false, false, `one:`)
+	test(NamesXml, false, false, `-one`)
+	test(NamesXml, false, false, `1one`)
+	test(NamesXml, false, false, `one/two`)
+	test(NamesXml, false, false, `@click`)
+}
+
+func TestNameError(t *testing.T) {
+	ctx := Ctx{Dialect: DialectHtml}
+
+	test := func(exp NameError, msg string, val any) {
+		t.Helper()
+		defer func() {
+			t.Helper()
+			err, _ := recover().(error)
+			var act NameError
+			if !errors.As(err, &act) {
+				t.Fatalf(`expected NameError, got %#v`, err)
+			}
+			eq(t, fmt.Sprint(act.Path), fmt.Sprint(exp.Path))
+			act.Path, exp.Path = nil, nil
+			eq(t, act, exp)
+			eq(t, err.Error(), msg)
+		}()
+		ctx.F(val)
+	}
+
+	test(
+		NameError{Tag: `a/b`, Path: []string{`html`, `body`}, Names: NamesHtml},
+		`[gax] invalid tag name "a/b" (html) at "html > body"`,
+		E(`html`, nil, E(`body`, nil, E(`a/b`, nil))),
+	)
+	test(
+		NameError{Tag: `div`, Attr: `onclick'x`, Path: []string{`body`}, Names: NamesHtml},
+		`[gax] invalid attribute name "onclick'x" in element "div" (html) at "body"`,
+		E(`body`, nil, E(`div`, AP(`onclick'x`, ``))),
+	)
+	test(
+		NameError{Tag: `path`, Attr: `a/b`, Path: []string{`div`, `svg`}, Names: NamesXml},
+		`[gax] invalid attribute name "a/b" in element "path" (xml) at "div > svg"`,
+		E(`div`, nil, E(`svg`, nil, E(`path`, AP(`a/b`, ``)))),
+	)
+
+	defer func() { eq(t, fmt.Sprint(recover()), `[gax] invalid tag name "a b"`) }()
+	F(E(`a b`, nil))
+}
+
+func TestNames_custom(t *testing.T) {
+	conf := DialectHtml.Conf()
+	conf.Names = NamesPermissive
+	eqs(t, (&Ctx{Dialect: NewDialect(conf)}).F(E(`a/b`, AP(`@click`, `one`))), `<a/b @click="one"></a/b>`)
+	eqs(t, (&Ctx{Dialect: DialectHtml}).F(E(`my-elem`, AP(`@click`, `one`))), `<my-elem @click="one"></my-elem>`)
+}
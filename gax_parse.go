@@ -0,0 +1,464 @@
+package gax
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+Parses HTML into a tree of `Elem`, text as `string`, and `Comment`. Never
+fails: like a browser, this recovers from any malformed input. The result can
+be inspected, modified, and rendered back via `Bui` or `F`. Usage:
+
+	frag := gax.ParseHtml(`<p>Hello <b>world</b>!</p>`)
+	fmt.Println(gax.E(`div`, nil, frag))
+
+Guided by the HTML5 specification, but simplified. Supported:
+
+	* Void elements (see `DialectHtml`), which never have children.
+	* Raw text elements such as `script` and `style`, and RCDATA elements such
+	  as `textarea` and `title`.
+	* Character references in text and attribute values.
+	* Implied end tags, such as for `p`, `li`, `dt`, `dd`, `option`, table
+	  rows and cells, and more.
+	* Foreign content (`svg` and `math`), including self-closing tags, CDATA
+	  sections, and the case of SVG element and attribute names.
+
+Unlike a browser, this doesn't synthesize missing `html`, `head`, `body`,
+`tbody` elements, doesn't relocate content misplaced in tables, and doesn't
+reconstruct misnested formatting elements. This keeps the result close to the
+source, and makes this suitable for fragments as well as entire documents. A
+doctype, if any, is represented as `Str`, usually `Str(Doctype)`.
+*/
+func ParseHtml(src string) Frag {
+	par := parser{tok: tokenizer{src: src}}
+	for {
+		val, ok := par.tok.next()
+		if !ok {
+			break
+		}
+		par.token(val)
+		par.tok.foreign = par.foreign() >= 0
+	}
+	return par.frag()
+}
+
+/*
+Parses well-formed XML into a tree of `Elem`, text as `string`, and `Comment`,
+preserving the case of names, including namespace prefixes. Unlike `ParseHtml`,
+this is strict, and returns an error for malformed input, including mismatched
+or unclosed tags and unknown entities. CDATA sections become text. The XML
+declaration, processing instructions and doctypes are skipped.
+*/
+func ParseXml(src string) (Frag, error) {
+	dec := xml.NewDecoder(strings.NewReader(src))
+	var out tree
+
+	for {
+		tok, err := dec.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf(`[gax] can't parse XML: %w`, err)
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			attrs := make(Attrs, 0, len(tok.Attr))
+			for _, val := range tok.Attr {
+				attrs = append(attrs, Attr{xmlName(val.Name), val.Value})
+			}
+			out.push(xmlName(tok.Name), attrs)
+
+		case xml.EndElement:
+			tag := xmlName(tok.Name)
+			if top := out.top(); top.tag != tag {
+				return nil, fmt.Errorf(`[gax] can't parse XML: unexpected end tag %q, expected %q`, tag, top.tag)
+			}
+			out.pop()
+
+		case xml.CharData:
+			out.text(string(tok))
+
+		case xml.Comment:
+			out.append(Comment(tok))
+		}
+	}
+
+	if len(out.stack) > 1 {
+		return nil, fmt.Errorf(`[gax] can't parse XML: unclosed element %q`, out.top().tag)
+	}
+	return out.frag(), nil
+}
+
+func xmlName(val xml.Name) string {
+	if val.Space == `` {
+		return val.Local
+	}
+	return val.Space + `:` + val.Local
+}
+
+/*
+Stack of open elements used by parsers. The bottom entry is the root, which
+collects top-level nodes. The zero value is ready to use.
+*/
+type tree struct{ stack []treeElem }
+
+type treeElem struct {
+	tag   string
+	attrs Attrs
+	child []any
+}
+
+func (self *tree) top() *treeElem {
+	if len(self.stack) == 0 {
+		self.stack = append(self.stack, treeElem{})
+	}
+	return &self.stack[len(self.stack)-1]
+}
+
+func (self *tree) push(tag string, attrs Attrs) {
+	self.top()
+	self.stack = append(self.stack, treeElem{tag: tag, attrs: attrs})
+}
+
+func (self *tree) pop() {
+	top := *self.top()
+	if len(self.stack) <= 1 {
+		return
+	}
+	self.stack = self.stack[:len(self.stack)-1]
+
+	if top.child == nil {
+		self.append(Elem{top.tag, top.attrs, nil})
+	} else {
+		self.append(Elem{top.tag, top.attrs, top.child})
+	}
+}
+
+// Pops the element at the given index, and all elements above it.
+func (self *tree) popTo(ind int) {
+	for ind > 0 && len(self.stack) > ind {
+		self.pop()
+	}
+}
+
+/*
+Returns the index of the topmost open element with the given tag, or -1 if
+there is none, or if an element listed in `stop` is encountered first.
+*/
+func (self *tree) find(tag string, stop stringSet) int {
+	for ind := len(self.stack) - 1; ind > 0; ind-- {
+		val := self.stack[ind].tag
+		if val == tag {
+			return ind
+		}
+		if stop.Has(val) {
+			break
+		}
+	}
+	return -1
+}
+
+func (self *tree) text(val string) {
+	if val == `` {
+		return
+	}
+
+	top := self.top()
+	if len(top.child) > 0 {
+		if prev, ok := top.child[len(top.child)-1].(string); ok {
+			top.child[len(top.child)-1] = prev + val
+			return
+		}
+	}
+	top.child = append(top.child, val)
+}
+
+func (self *tree) append(val any) {
+	top := self.top()
+	top.child = append(top.child, val)
+}
+
+// Closes all open elements and returns the top-level nodes.
+func (self *tree) frag() Frag {
+	self.popTo(1)
+	return Frag(self.top().child)
+}
+
+/*
+Elements which limit the search for open elements in several tree-building
+rules. Simplified version of "has an element in scope". Reference:
+
+	https://html.spec.whatwg.org/multipage/parsing.html#has-an-element-in-scope
+*/
+var parseScope = newStringSet(
+	`applet`, `button`, `caption`, `html`, `marquee`, `math`, `object`, `svg`,
+	`table`, `td`, `template`, `th`,
+)
+
+// Start tags which close an open `p` element.
+var parseCloseP = newStringSet(
+	`address`, `article`, `aside`, `blockquote`, `center`, `dd`, `details`,
+	`dialog`, `dir`, `div`, `dl`, `dt`, `fieldset`, `figcaption`, `figure`,
+	`footer`, `form`, `h1`, `h2`, `h3`, `h4`, `h5`, `h6`, `header`, `hgroup`,
+	`hr`, `li`, `listing`, `main`, `menu`, `nav`, `ol`, `p`, `plaintext`, `pre`,
+	`search`, `section`, `summary`, `table`, `ul`, `xmp`,
+)
+
+var parseHeading = newStringSet(`h1`, `h2`, `h3`, `h4`, `h5`, `h6`)
+
+// Start tags which break out of foreign content back into HTML.
+var parseBreakout = newStringSet(
+	`b`, `big`, `blockquote`, `body`, `br`, `center`, `code`, `dd`, `div`, `dl`,
+	`dt`, `em`, `embed`, `h1`, `h2`, `h3`, `h4`, `h5`, `h6`, `head`, `hr`, `i`,
+	`img`, `li`, `listing`, `menu`, `meta`, `nobr`, `ol`, `p`, `pre`, `ruby`, `s`,
+	`small`, `span`, `strike`, `strong`, `sub`, `sup`, `table`, `tt`, `u`, `ul`,
+	`var`,
+)
+
+/*
+The tokenizer lowercases all names, but SVG is case-sensitive. Reference:
+
+	https://html.spec.whatwg.org/multipage/parsing.html#parsing-main-inforeign
+*/
+var parseSvgNames = parseCaseMap(
+	`altGlyph`, `altGlyphDef`, `altGlyphItem`, `animateColor`, `animateMotion`,
+	`animateTransform`, `clipPath`, `feBlend`, `feColorMatrix`,
+	`feComponentTransfer`, `feComposite`, `feConvolveMatrix`,
+	`feDiffuseLighting`, `feDisplacementMap`, `feDistantLight`, `feDropShadow`,
+	`feFlood`, `feFuncA`, `feFuncB`, `feFuncG`, `feFuncR`, `feGaussianBlur`,
+	`feImage`, `feMerge`, `feMergeNode`, `feMorphology`, `feOffset`,
+	`fePointLight`, `feSpecularLighting`, `feSpotLight`, `feTile`,
+	`feTurbulence`, `foreignObject`, `glyphRef`, `linearGradient`,
+	`radialGradient`, `textPath`,
+)
+
+var parseSvgAttrs = parseCaseMap(
+	`attributeName`, `attributeType`, `baseFrequency`, `baseProfile`, `calcMode`,
+	`clipPathUnits`, `diffuseConstant`, `edgeMode`, `filterUnits`, `glyphRef`,
+	`gradientTransform`, `gradientUnits`, `kernelMatrix`, `kernelUnitLength`,
+	`keyPoints`, `keySplines`, `keyTimes`, `lengthAdjust`, `limitingConeAngle`,
+	`markerHeight`, `markerUnits`, `markerWidth`, `maskContentUnits`,
+	`maskUnits`, `numOctaves`, `pathLength`, `patternContentUnits`,
+	`patternTransform`, `patternUnits`, `pointsAtX`, `pointsAtY`, `pointsAtZ`,
+	`preserveAlpha`, `preserveAspectRatio`, `primitiveUnits`, `refX`, `refY`,
+	`repeatCount`, `repeatDur`, `requiredExtensions`, `requiredFeatures`,
+	`specularConstant`, `specularExponent`, `spreadMethod`, `startOffset`,
+	`stdDeviation`, `stitchTiles`, `surfaceScale`, `systemLanguage`,
+	`tableValues`, `targetX`, `targetY`, `textLength`, `viewBox`, `viewTarget`,
+	`xChannelSelector`, `yChannelSelector`, `zoomAndPan`,
+)
+
+func parseCaseMap(vals ...string) map[string]string {
+	out := make(map[string]string, len(vals))
+	for _, val := range vals {
+		out[strings.ToLower(val)] = val
+	}
+	return out
+}
+
+func parseSvgName(names map[string]string, val string) string {
+	if out, ok := names[val]; ok {
+		return out
+	}
+	return val
+}
+
+// HTML tree builder. See `ParseHtml`.
+type parser struct {
+	tree
+	tok     tokenizer
+	newline bool
+}
+
+func (self *parser) token(val token) {
+	newline := self.newline
+	self.newline = false
+
+	switch val.typ {
+	case tokText:
+		if newline {
+			val.data = strings.TrimPrefix(val.data, "\n")
+		}
+		self.text(val.data)
+
+	case tokComment:
+		self.append(Comment(val.data))
+
+	case tokDoctype:
+		if strings.EqualFold(val.data, `html`) {
+			self.append(Str(Doctype))
+		} else {
+			self.append(Str(`<!doctype ` + val.data + `>`))
+		}
+
+	case tokStart:
+		self.start(val)
+
+	case tokEnd:
+		self.end(val)
+	}
+}
+
+func (self *parser) start(val token) {
+	if ind := self.foreign(); ind >= 0 {
+		if !parseBreakout.Has(val.name) {
+			self.startForeign(val, self.stack[ind].tag == `svg`)
+			return
+		}
+		self.popTo(ind)
+	}
+
+	if val.name == `svg` || val.name == `math` {
+		self.startForeign(val, val.name == `svg`)
+		return
+	}
+
+	tag := val.name
+	if parseCloseP.Has(tag) {
+		self.close(`p`, parseScope)
+	}
+
+	switch tag {
+	case `body`:
+		self.popIf(`head`)
+	case `li`:
+		self.closeAny([]string{`li`}, true, `ol`, `ul`, `menu`)
+	case `dd`, `dt`:
+		self.closeAny([]string{`dd`, `dt`}, true, `dl`)
+	case `h1`, `h2`, `h3`, `h4`, `h5`, `h6`:
+		if parseHeading.Has(self.top().tag) {
+			self.pop()
+		}
+	case `a`, `button`, `nobr`:
+		self.close(tag, parseScope)
+	case `option`:
+		self.popIf(`option`)
+	case `optgroup`:
+		self.popIf(`option`)
+		self.popIf(`optgroup`)
+	case `colgroup`:
+		self.closeAny([]string{`caption`, `colgroup`}, false, `table`)
+	case `tr`:
+		self.closeAny([]string{`tr`, `caption`, `colgroup`}, false, `table`, `tbody`, `thead`, `tfoot`)
+	case `td`, `th`:
+		self.closeAny([]string{`td`, `th`}, false, `tr`, `table`)
+	case `tbody`, `thead`, `tfoot`:
+		self.closeAny([]string{`tbody`, `thead`, `tfoot`, `caption`, `colgroup`}, false, `table`)
+	case `rb`, `rtc`:
+		self.closeAny([]string{`rb`, `rt`, `rp`, `rtc`}, true, `ruby`)
+	case `rt`, `rp`:
+		self.closeAny([]string{`rb`, `rt`, `rp`}, true, `ruby`, `rtc`)
+	}
+
+	if DialectHtml.IsVoid(tag) {
+		self.append(Elem{tag, val.attrs, nil})
+		return
+	}
+
+	self.push(tag, val.attrs)
+	self.newline = tag == `pre` || tag == `textarea` || tag == `listing`
+}
+
+func (self *parser) startForeign(val token, svg bool) {
+	tag := val.name
+	attrs := val.attrs
+
+	if svg {
+		tag = parseSvgName(parseSvgNames, tag)
+		for ind, attr := range attrs {
+			attrs[ind][0] = parseSvgName(parseSvgAttrs, attr.Name())
+		}
+	}
+
+	if val.self {
+		self.append(Elem{tag, attrs, nil})
+		return
+	}
+	self.push(tag, attrs)
+}
+
+func (self *parser) end(val token) {
+	tag := val.name
+
+	if ind := self.foreign(); ind >= 0 {
+		if self.stack[ind].tag == `svg` {
+			tag = parseSvgName(parseSvgNames, tag)
+		}
+		if found := self.find(tag, nil); found >= ind {
+			self.popTo(found)
+			return
+		}
+	}
+
+	switch tag {
+	case `p`:
+		if !self.close(`p`, parseScope) {
+			self.append(Elem{`p`, nil, nil})
+		}
+	case `br`:
+		self.append(Elem{`br`, nil, nil})
+	case `li`:
+		self.closeAny([]string{`li`}, true, `ol`, `ul`, `menu`)
+	case `dd`, `dt`:
+		self.closeAny([]string{tag}, true, `dl`)
+	case `table`:
+		self.closeAny([]string{tag}, false)
+	case `tr`, `td`, `th`, `tbody`, `thead`, `tfoot`, `caption`:
+		self.closeAny([]string{tag}, false, `table`)
+	default:
+		self.close(tag, parseScope)
+	}
+}
+
+/*
+Returns the index of the outermost open `svg` or `math` element, or -1 if not
+in foreign content.
+*/
+func (self *parser) foreign() int {
+	for ind := 1; ind < len(self.stack); ind++ {
+		switch self.stack[ind].tag {
+		case `svg`, `math`:
+			return ind
+		}
+	}
+	return -1
+}
+
+// Closes the topmost open element with the given tag, if it's in scope.
+func (self *parser) close(tag string, stop stringSet) bool {
+	if ind := self.find(tag, stop); ind > 0 {
+		self.popTo(ind)
+		return true
+	}
+	return false
+}
+
+/*
+Closes the topmost open element with any of the given tags, unless one of the
+given stop tags is encountered first. If `scope` is true, elements listed in
+`parseScope` also stop the search.
+*/
+func (self *parser) closeAny(tags []string, scope bool, stop ...string) {
+	for ind := len(self.stack) - 1; ind > 0; ind-- {
+		val := self.stack[ind].tag
+		if hasString(tags, val) {
+			self.popTo(ind)
+			return
+		}
+		if (scope && parseScope.Has(val)) || hasString(stop, val) {
+			return
+		}
+	}
+}
+
+func (self *parser) popIf(tag string) {
+	if self.top().tag == tag {
+		self.pop()
+	}
+}
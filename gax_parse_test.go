@@ -0,0 +1,154 @@
+package gax
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseHtml(t *testing.T) {
+	test := func(src, exp string) {
+		t.Helper()
+		eqs(t, ParseHtml(src), exp)
+	}
+
+	test(``, ``)
+	test(`one`, `one`)
+	test(`<div class="one">two</div>`, `<div class="one">two</div>`)
+	test(`<DIV CLASS=one>two</DIV>`, `<div class="one">two</div>`)
+	test(`one &amp; &lt;two&gt; &copy; &#x41;`, `one &amp; &lt;two&gt; © A`)
+	test(`<a title="&quot;one&quot;" href='/two?a=1&amp;b=2'>`, `<a title="&quot;one&quot;" href="/two?a=1&amp;b=2"></a>`)
+	test(`<input type=checkbox checked>`, `<input type="checkbox" checked="">`)
+	test(`<!doctype html><p>one`, `<!doctype html><p>one</p>`)
+	test(`<!-- one -->two`, `<!-- one -->two`)
+	test(`<br/>one<br>`, `<br>one<br>`)
+	test(`<div/>one`, `<div>one</div>`)
+	test(`</br>`, `<br>`)
+	test(`one</p>`, `one<p></p>`)
+	test(`one</div>two`, `onetwo`)
+
+	test(`<p>one<p>two`, `<p>one</p><p>two</p>`)
+	test(`<p>one<div>two</div>`, `<p>one</p><div>two</div>`)
+	test(`<p>one<span>two</span>`, `<p>one<span>two</span></p>`)
+	test(`<p><b>one<p>two`, `<p><b>one</b></p><p>two</p>`)
+	test(`<ul><li>one<li>two</ul>`, `<ul><li>one</li><li>two</li></ul>`)
+	test(`<ul><li>one<ul><li>two</ul><li>three</ul>`, `<ul><li>one<ul><li>two</li></ul></li><li>three</li></ul>`)
+	test(`<dl><dt>one<dd>two<dt>three</dl>`, `<dl><dt>one</dt><dd>two</dd><dt>three</dt></dl>`)
+	test(`<h1>one<h2>two`, `<h1>one</h1><h2>two</h2>`)
+	test(`<select><option>one<option>two</select>`, `<select><option>one</option><option>two</option></select>`)
+	test(`<a>one<a>two`, `<a>one</a><a>two</a>`)
+	test(`<button>one<button>two`, `<button>one</button><button>two</button>`)
+	test(
+		`<table><tr><td>one<td>two<tr><th>three</table>`,
+		`<table><tr><td>one</td><td>two</td></tr><tr><th>three</th></tr></table>`,
+	)
+	test(
+		`<table><thead><tr><td>one<tbody><tr><td>two</table>`,
+		`<table><thead><tr><td>one</td></tr></thead><tbody><tr><td>two</td></tr></tbody></table>`,
+	)
+	test(
+		`<table><caption>one<colgroup><col><tr><td>two</table>`,
+		`<table><caption>one</caption><colgroup><col></colgroup><tr><td>two</td></tr></table>`,
+	)
+	test(`<html><head><title>one</title><body>two`, `<html><head><title>one</title></head><body>two</body></html>`)
+	test(`<table><tr><td><b>one</td></tr></table>`, `<table><tr><td><b>one</b></td></tr></table>`)
+	test(`<b><table><td></b>one</table>`, `<b><table><td>one</td></table></b>`)
+	test(`<ruby>one<rt>two<rp>three</ruby>`, `<ruby>one<rt>two</rt><rp>three</rp></ruby>`)
+	test(`<b><i>one</b>two</i>`, `<b><i>one</i></b>two`)
+
+	test(`<script>if (a < b && c) {}</script>`, `<script>if (a < b && c) {}</script>`)
+	test(`<style>a > b {}</style>`, `<style>a > b {}</style>`)
+	test(`<script>if (a <!--b) {}</script>`, `<script>if (a <!--b) {}</script>`)
+	test(`<script>"<!--<script>"</script>`, `<script>"<\!--<script>"</script>`)
+	test(`<script>"<!--<script></scripts>"</script>`, `<script>"<\!--<script><\/scripts>"</script>`)
+	test(`<style></styles></style>`, `<style></styles></style>`)
+	test("<a \x01href=\"javascript:alert(1)\">one</a>", "<a \uFFFDhref=\"javascript:alert(1)\">one</a>")
+	test("<a \x02onclick=\"alert(1)\">one</a>", "<a \uFFFDonclick=\"alert(1)\">one</a>")
+	test("<one\x01>two</one\x01>", "<one\uFFFD>two</one\uFFFD>")
+	test(`<a"b>one`, "<a\uFFFDb>one</a\uFFFDb>")
+	test(`<a<b>one`, "<a\uFFFDb>one</a\uFFFDb>")
+	test(`<p a"b=1>`, "<p a\uFFFDb=\"1\"></p>")
+	test(`<p <x=1>`, "<p \uFFFDx=\"1\"></p>")
+	test(`<p =x>`, "<p \uFFFDx=\"\"></p>")
+	test(`<title>one &amp; <b>two</b></title>`, `<title>one &amp; &lt;b&gt;two&lt;/b&gt;</title>`)
+	test(`<textarea>one</textarea >two`, `<textarea>one</textarea>two`)
+	test("<pre>\none</pre>", `<pre>one</pre>`)
+	test("<pre>\n\none</pre>", "<pre>\none</pre>")
+
+	test(
+		`<svg viewbox="0 0 1 1"><lineargradient/><foreignobject>one</foreignobject></svg>`,
+		`<svg viewBox="0 0 1 1"><linearGradient></linearGradient><foreignObject>one</foreignObject></svg>`,
+	)
+	test(`<svg><path d=""/><g></svg>one`, `<svg><path d=""></path><g></g></svg>one`)
+	test(`<svg><style>a &gt; b {}</style></svg>`, `<svg><style>a > b {}</style></svg>`)
+	test(`<svg><![CDATA[one<two>]]></svg>`, `<svg>one&lt;two&gt;</svg>`)
+	test(`<svg><g><p>one</svg>`, `<svg><g></g></svg><p>one</p>`)
+	test(`<math><mi>one</mi></math>`, `<math><mi>one</mi></math>`)
+}
+
+func TestParseHtml_tree(t *testing.T) {
+	eq(
+		t,
+		ParseHtml(`<!doctype html><div id="one">two<!--three--><br></div>four`),
+		Frag{
+			Str(Doctype),
+			E(`div`, AP(`id`, `one`), `two`, Comment(`three`), E(`br`, nil)),
+			`four`,
+		},
+	)
+
+	eq(t, ParseHtml(`<!DOCTYPE svg>`), Frag{Str(`<!doctype svg>`)})
+}
+
+func TestParseHtml_roundtrip(t *testing.T) {
+	src := renderDynamic(mockDat).String()
+	eq(t, ParseHtml(src).String(), src)
+}
+
+func TestParseXml(t *testing.T) {
+	test := func(src, exp string) {
+		t.Helper()
+		out, err := ParseXml(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		eqs(t, out, exp)
+	}
+
+	test(`<?xml version="1.0"?><one/>`, `<one></one>`)
+	test(
+		`<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="one"><media:thumbnail URL="two"/></feed>`,
+		`<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="one"><media:thumbnail URL="two"></media:thumbnail></feed>`,
+	)
+	test(`<one>two &amp; &#x41; <![CDATA[<three>]]></one>`, `<one>two &amp; A &lt;three&gt;</one>`)
+	test(`<one><!-- two --></one>`, `<one><!-- two --></one>`)
+	test(`<Data>one &lt; two</Data>`, `<Data>one &lt; two</Data>`)
+
+	fail := func(src, exp string) {
+		t.Helper()
+		_, err := ParseXml(src)
+		if err == nil || !strings.Contains(err.Error(), exp) {
+			t.Fatalf(`expected error containing %q, got %v`, exp, err)
+		}
+	}
+
+	fail(`<one><two></one>`, `unexpected end tag "one", expected "two"`)
+	fail(`<one>`, `unclosed element "one"`)
+	fail(`<one>&nbsp;</one>`, `[gax] can't parse XML`)
+	fail(`<one two=three/>`, `[gax] can't parse XML`)
+}
+
+func TestComment(t *testing.T) {
+	eqs(t, Comment(` one `), `<!-- one -->`)
+	eqs(t, Comment(``), `<!---->`)
+
+	for _, val := range []string{`>`, `->`, `one-->`, `one--!>two`} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf(`expected panic for comment %q`, val)
+				}
+			}()
+			_ = Comment(val).String()
+		}()
+	}
+}
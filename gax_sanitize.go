@@ -0,0 +1,261 @@
+package gax
+
+/*
+Allowlist-based policy for sanitizing untrusted HTML via `Policy.Sanitize`.
+Anything not explicitly allowed is removed. Built-in policies are available
+via `PolicyText`, `PolicyBasic`, `PolicyUgc`. Each call returns a new policy,
+which may be freely modified, for example:
+
+	policy := gax.PolicyUgc()
+	policy.Elems[`span`] = append(policy.Elems[`span`], `class`)
+
+Fields:
+
+	* `.Elems`: allowed elements, mapped to their allowed attributes. Element
+	  and attribute names must be lowercase.
+	* `.Attrs`: attributes allowed on every allowed element.
+	* `.Drop`: elements removed together with their entire content. Other
+	  disallowed elements are unwrapped: their tags are removed, but their
+	  content is kept, subject to the same policy.
+	* `.Rel`: if non-empty, every allowed `a` element with an `href` gets the
+	  attribute `rel` with this value, replacing any previous value.
+
+Regardless of policy, event handler attributes such as `onclick` are always
+removed, and attributes listed in `UrlAttrs` are removed unless their values
+pass the `UrlSchemes` check. Allowing `style` permits arbitrary CSS, and is
+not recommended for untrusted input.
+*/
+type Policy struct {
+	Elems map[string][]string
+	Attrs []string
+	Drop  []string
+	Rel   string
+}
+
+/*
+Returns a policy which allows no elements at all, keeping only text. Content of
+elements such as `script` and `style` is removed, rather than converted to
+text.
+*/
+func PolicyText() Policy {
+	return Policy{Elems: map[string][]string{}, Drop: sanitizeDrop()}
+}
+
+/*
+Returns a policy which allows basic inline formatting, paragraphs, lists, quotes
+and code, without links or images.
+*/
+func PolicyBasic() Policy {
+	return Policy{
+		Elems: map[string][]string{
+			`abbr`:       {`title`},
+			`b`:          nil,
+			`blockquote`: nil,
+			`br`:         nil,
+			`cite`:       nil,
+			`code`:       nil,
+			`del`:        nil,
+			`dfn`:        nil,
+			`em`:         nil,
+			`hr`:         nil,
+			`i`:          nil,
+			`ins`:        nil,
+			`kbd`:        nil,
+			`li`:         nil,
+			`mark`:       nil,
+			`ol`:         nil,
+			`p`:          nil,
+			`pre`:        nil,
+			`q`:          nil,
+			`s`:          nil,
+			`samp`:       nil,
+			`small`:      nil,
+			`span`:       nil,
+			`strong`:     nil,
+			`sub`:        nil,
+			`sup`:        nil,
+			`time`:       {`datetime`},
+			`u`:          nil,
+			`ul`:         nil,
+			`var`:        nil,
+			`wbr`:        nil,
+		},
+		Attrs: []string{`dir`, `lang`},
+		Drop:  sanitizeDrop(),
+	}
+}
+
+/*
+Returns a policy suitable for user-generated content such as comments and
+posts: everything allowed by `PolicyBasic`, plus links, images, headings,
+tables and a few other structural elements. Links get `rel="nofollow noopener
+noreferrer"`.
+*/
+func PolicyUgc() Policy {
+	out := PolicyBasic()
+	out.Rel = `nofollow noopener noreferrer`
+	out.Attrs = append(out.Attrs, `title`)
+
+	for key, val := range map[string][]string{
+		`a`:          {`href`},
+		`blockquote`: {`cite`},
+		`caption`:    nil,
+		`dd`:         nil,
+		`del`:        {`cite`, `datetime`},
+		`details`:    {`open`},
+		`dl`:         nil,
+		`dt`:         nil,
+		`figcaption`: nil,
+		`figure`:     nil,
+		`h1`:         nil,
+		`h2`:         nil,
+		`h3`:         nil,
+		`h4`:         nil,
+		`h5`:         nil,
+		`h6`:         nil,
+		`img`:        {`alt`, `height`, `src`, `width`},
+		`ins`:        {`cite`, `datetime`},
+		`li`:         {`value`},
+		`ol`:         {`reversed`, `start`, `type`},
+		`q`:          {`cite`},
+		`summary`:    nil,
+		`table`:      nil,
+		`tbody`:      nil,
+		`td`:         {`colspan`, `rowspan`},
+		`tfoot`:      nil,
+		`th`:         {`colspan`, `rowspan`, `scope`},
+		`thead`:      nil,
+		`tr`:         nil,
+	} {
+		out.Elems[key] = val
+	}
+	return out
+}
+
+func sanitizeDrop() []string {
+	return []string{
+		`applet`, `embed`, `frame`, `frameset`, `iframe`, `math`, `noembed`,
+		`noframes`, `noscript`, `object`, `script`, `select`, `style`, `svg`,
+		`template`, `textarea`, `title`, `xmp`,
+	}
+}
+
+/*
+Parses the given untrusted HTML and returns a tree of `Elem` and text, which
+contains only elements and attributes allowed by the policy. Comments, doctypes
+and processing instructions are removed. Character references are decoded, so
+the resulting text is plain, and is escaped again when rendering. Unclosed
+elements are closed at the end of input, and stray end tags are ignored, so
+the result can't affect any surrounding markup.
+
+The result is a regular tree, which can be inspected, modified, and rendered
+anywhere, for example as a child of `E`.
+*/
+func (self Policy) Sanitize(src string) Frag {
+	san := sanitizer{policy: self}
+	tok := tokenizer{src: src}
+
+	for {
+		val, ok := tok.next()
+		if !ok {
+			break
+		}
+		san.token(val)
+	}
+
+	return san.frag()
+}
+
+type sanitizer struct {
+	tree
+	policy Policy
+	drop   string
+	depth  int
+}
+
+func (self *sanitizer) token(val token) {
+	if self.drop != `` {
+		if val.name != self.drop {
+			return
+		}
+		if val.typ == tokStart && !val.self && !DialectHtml.IsVoid(val.name) {
+			self.depth++
+		} else if val.typ == tokEnd {
+			self.depth--
+			if self.depth == 0 {
+				self.drop = ``
+			}
+		}
+		return
+	}
+
+	switch val.typ {
+	case tokText:
+		self.text(val.data)
+
+	case tokStart:
+		if hasString(self.policy.Drop, val.name) {
+			if !val.self && !DialectHtml.IsVoid(val.name) {
+				self.drop, self.depth = val.name, 1
+			}
+			return
+		}
+
+		allowed, ok := self.policy.Elems[val.name]
+		if !ok {
+			return
+		}
+
+		attrs := self.policy.attrs(val.name, allowed, val.attrs)
+		if DialectHtml.IsVoid(val.name) {
+			self.append(Elem{val.name, attrs, nil})
+			return
+		}
+		self.push(val.name, attrs)
+
+	case tokEnd:
+		if ind := self.find(val.name, nil); ind > 0 {
+			self.popTo(ind)
+		}
+	}
+}
+
+func (self Policy) attrs(tag string, allowed []string, src Attrs) (out Attrs) {
+	for _, val := range src {
+		key := val.Name()
+		if !hasString(allowed, key) && !hasString(self.Attrs, key) {
+			continue
+		}
+		if !sanitizeValue(key, val.Value()) {
+			continue
+		}
+		out = append(out, Attr{key, val.Value()})
+	}
+
+	if tag == `a` && self.Rel != `` && attrsHas(out, `href`) {
+		out = out.Set(`rel`, self.Rel)
+	}
+	return
+}
+
+func sanitizeValue(key, val string) bool {
+	switch attrCtxOf(key) {
+	case ctxUrl:
+		return isUrlSafe(val)
+	case ctxSrcset:
+		return srcsetSafe(val) == val
+	case ctxJs:
+		return false
+	default:
+		return true
+	}
+}
+
+func hasString(vals []string, val string) bool {
+	for _, elem := range vals {
+		if elem == val {
+			return true
+		}
+	}
+	return false
+}
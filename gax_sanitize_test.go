@@ -0,0 +1,153 @@
+package gax
+
+import "testing"
+
+func TestPolicyText(t *testing.T) {
+	test := func(src, exp string) {
+		t.Helper()
+		eqs(t, PolicyText().Sanitize(src), exp)
+	}
+
+	test(``, ``)
+	test(`one`, `one`)
+	test(`<b>one</b> <i>two</i>`, `one two`)
+	test(`one &amp; two &lt; three &#x3e; four`, `one &amp; two &lt; three &gt; four`)
+	test(`one<script>alert(1)</script>two`, `onetwo`)
+	test(`one<style>body {}</style>two`, `onetwo`)
+	test(`one<!-- two -->three`, `onethree`)
+	test(`<!doctype html>one`, `one`)
+	test(`one < two`, `one &lt; two`)
+	test(`one <3 two`, `one &lt;3 two`)
+	test(`<textarea><b>one</b></textarea>two`, `two`)
+	test(`<svg><svg></svg><b>one</b></svg>two`, `two`)
+	test(`one<img src=x onerror=alert(1)`, `one`)
+}
+
+func TestPolicyBasic(t *testing.T) {
+	test := func(src, exp string) {
+		t.Helper()
+		eqs(t, PolicyBasic().Sanitize(src), exp)
+	}
+
+	test(`<b>one</b> <i>two</i>`, `<b>one</b> <i>two</i>`)
+	test(`<B CLASS=one>two</B>`, `<b>two</b>`)
+	test(`<p lang=en dir="rtl" style="color: red">one</p>`, `<p lang="en" dir="rtl">one</p>`)
+	test(`<p onclick="alert(1)">one</p>`, `<p>one</p>`)
+	test(`<a href="/one">two</a>`, `two`)
+	test(`<div><p>one</p></div>`, `<p>one</p>`)
+	test(`one<br>two<br/>three`, `one<br>two<br>three`)
+	test(`<b><i>one</b>two`, `<b><i>one</i></b>two`)
+	test(`<b>one`, `<b>one</b>`)
+	test(`one</b></p>two`, `onetwo`)
+	test(`<p title="one">two</p>`, `<p>two</p>`)
+	test(`<p lang='one "two"'>three</p>`, `<p lang="one &quot;two&quot;">three</p>`)
+	test(`<p lang=one lang=two>three</p>`, `<p lang="one">three</p>`)
+	test(`<b>one</b><script>alert("</b>")</script>`, `<b>one</b>`)
+	test(`<p>one</script><b>two</b></p>`, `<p>one<b>two</b></p>`)
+	test(`<pre>one &lt;b&gt; two</pre>`, `<pre>one &lt;b&gt; two</pre>`)
+}
+
+func TestPolicyUgc(t *testing.T) {
+	test := func(src, exp string) {
+		t.Helper()
+		eqs(t, PolicyUgc().Sanitize(src), exp)
+	}
+
+	test(
+		`<a href="https://example.com" rel="author" target="_blank">one</a>`,
+		`<a href="https://example.com" rel="nofollow noopener noreferrer">one</a>`,
+	)
+	test(`<a href="javascript:alert(1)">one</a>`, `<a>one</a>`)
+	test(`<a href="java&#x09;script:alert(1)">one</a>`, `<a>one</a>`)
+	test(`<a href="&#106;avascript:alert(1)">one</a>`, `<a>one</a>`)
+	test(`<a title="one">two</a>`, `<a title="one">two</a>`)
+	test(`<img src="/one.png" alt="two" onerror="alert(1)">`, `<img src="/one.png" alt="two">`)
+	test(`<img src="data:image/svg+xml,<svg>">`, `<img>`)
+	test(`<img srcset="/one.png 1x">`, `<img>`)
+	test(
+		`<table><tr><td colspan=2>one</td></tr></table>`,
+		`<table><tr><td colspan="2">one</td></tr></table>`,
+	)
+	test(`<iframe src="/one">two</iframe>three`, `three`)
+	test(`<h1 id="one">two</h1>`, `<h1>two</h1>`)
+}
+
+func TestPolicy_custom(t *testing.T) {
+	policy := PolicyUgc()
+	policy.Elems[`span`] = append(policy.Elems[`span`], `class`)
+	policy.Elems[`img`] = append(policy.Elems[`img`], `onerror`)
+	policy.Rel = ``
+
+	eqs(t, policy.Sanitize(`<span class="one">two</span>`), `<span class="one">two</span>`)
+	eqs(t, policy.Sanitize(`<a href="/one">two</a>`), `<a href="/one">two</a>`)
+	eqs(t, policy.Sanitize(`<img onerror="alert(1)">`), `<img>`)
+
+	eqs(t, PolicyUgc().Sanitize(`<span class="one">two</span>`), `<span>two</span>`)
+}
+
+func TestPolicy_Sanitize_tree(t *testing.T) {
+	eq(
+		t,
+		PolicyBasic().Sanitize(`one<b>two<i>three</i></b><br>`),
+		Frag{`one`, E(`b`, nil, `two`, E(`i`, nil, `three`)), E(`br`, nil)},
+	)
+
+	eqs(t, E(`div`, nil, PolicyBasic().Sanitize(`<b>one</div>`)), `<div><b>one</b></div>`)
+}
+
+func TestTokenizer(t *testing.T) {
+	test := func(src string, exp ...token) {
+		t.Helper()
+		tok := tokenizer{src: src}
+		var out []token
+		for {
+			val, ok := tok.next()
+			if !ok {
+				break
+			}
+			out = append(out, val)
+		}
+		eq(t, out, exp)
+	}
+
+	test(``)
+	test(`one`, token{typ: tokText, data: `one`})
+	test(`<a>`, token{typ: tokStart, name: `a`})
+	test(`<a/>`, token{typ: tokStart, name: `a`, self: true})
+	test(`</A >`, token{typ: tokEnd, name: `a`})
+	test(`</>`)
+	test(`</ one>`, token{typ: tokComment, data: ` one`})
+	test(`<?xml one?>`, token{typ: tokComment, data: `xml one?`})
+	test(`<!---->`, token{typ: tokComment})
+	test(`<!-->`, token{typ: tokComment})
+	test(`<!-- one --!>`, token{typ: tokComment, data: ` one `})
+	test(`<!-- one`, token{typ: tokComment, data: ` one`})
+	test(`<!DOCTYPE html>`, token{typ: tokDoctype, data: `html`})
+	test(
+		`<a one two=three four = 'five' six="seven">`,
+		token{typ: tokStart, name: `a`, attrs: Attrs{
+			{`one`, ``}, {`two`, `three`}, {`four`, `five`}, {`six`, `seven`},
+		}},
+	)
+	test(`<a one="&lt;&amp;">`, token{typ: tokStart, name: `a`, attrs: Attrs{{`one`, `<&`}}})
+	test(`<a one`)
+	test(
+		`<script><b>&amp;</b></script>`,
+		token{typ: tokStart, name: `script`},
+		token{typ: tokText, data: `<b>&amp;</b>`},
+		token{typ: tokEnd, name: `script`},
+	)
+	test(
+		`<title><b>&amp;</b></TITLE>`,
+		token{typ: tokStart, name: `title`},
+		token{typ: tokText, data: `<b>&</b>`},
+		token{typ: tokEnd, name: `title`},
+	)
+	test(
+		`<style></styles></style>`,
+		token{typ: tokStart, name: `style`},
+		token{typ: tokText, data: `</styles>`},
+		token{typ: tokEnd, name: `style`},
+	)
+	test(`<script>`, token{typ: tokStart, name: `script`})
+}
@@ -0,0 +1,335 @@
+package gax
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+/*
+Internal per-render state of renders with settings, such as `Ctx.Into`,
+`Stream`, `Bui.With` and `Dialect.Into`. Since `Bui` is a plain byte slice, it
+can't carry additional fields. Instead, the state is associated with the
+builder's address, see `states`.
+
+On a plain builder, `Bui.Begin` attaches a temporary state when opening a raw
+text element, which is detached by the matching `Bui.End`, see `state.auto`.
+This way, raw text is written and validated the same way with or without
+settings.
+*/
+type state struct {
+	auto    bool // Attached by `Bui.Begin`, see `state.detach`.
+	ctx     *Ctx
+	stream  *Stream
+	raw     int
+	stack   []frame
+	tails   []tail
+	edge    mark
+	dialect *Dialect
+	context context.Context
+	errs    *[]error
+}
+
+/*
+Element opened via `Bui.Begin` and not yet closed via `Bui.End`. Tracked only
+when state is attached, for features which depend on ancestors, such as
+`Ctx.Indent` and `Ctx.Minify`.
+*/
+type frame struct {
+	tag    string
+	format bool     // Children may be formatted, see `Ctx.Indent`.
+	blocks bool     // Some children were formatted as blocks.
+	pre    bool     // Whitespace is significant, see `Verbatim`.
+	raw    bool     // Raw text element, see `Raw`.
+	open   mark     // Position after the start tag.
+	outer  *Dialect // Dialect of the parent, see `Dialect`.
+}
+
+/*
+States attached to builders, keyed by the builder's address, which remains
+stable for the entire render: `Bui.E`, `Bui.Child`, `Elem.Render` and the
+various renderer callbacks all receive the same pointer, including closures
+which capture it. Builders never attached, including separate builds via the
+function `F`, have no state.
+
+The table is split into shards by address, and each shard holds an immutable
+list, replaced on every change. When no state is attached to any builder,
+lookups cost a single atomic load. Otherwise they scan one short list, without
+locking, so concurrent renders don't contend with each other.
+*/
+var states stateTable
+
+type stateTable struct {
+	count  atomic.Int64
+	shards [stateShards]stateShard
+}
+
+type stateShard struct {
+	lock sync.Mutex
+	vals atomic.Pointer[[]stateEntry]
+}
+
+type stateEntry struct {
+	bui   *Bui
+	state *state
+}
+
+const stateShards = 64
+
+func (self *stateTable) shard(bui *Bui) *stateShard {
+	return &self.shards[(uintptr(unsafe.Pointer(bui))>>4)%stateShards]
+}
+
+func (self *stateTable) get(bui *Bui) *state {
+	if bui == nil || self.count.Load() == 0 {
+		return nil
+	}
+
+	vals := self.shard(bui).vals.Load()
+	if vals == nil {
+		return nil
+	}
+	for _, val := range *vals {
+		if val.bui == bui {
+			return val.state
+		}
+	}
+	return nil
+}
+
+/*
+Associates the given state with the builder, returning the previous one.
+Passing nil removes the association.
+*/
+func (self *stateTable) swap(bui *Bui, next *state) (prev *state) {
+	shard := self.shard(bui)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	var vals []stateEntry
+	if prev := shard.vals.Load(); prev != nil {
+		vals = make([]stateEntry, 0, len(*prev)+1)
+		vals = append(vals, *prev...)
+	}
+
+	for ind, val := range vals {
+		if val.bui == bui {
+			prev = val.state
+			vals = append(vals[:ind], vals[ind+1:]...)
+			break
+		}
+	}
+	if next != nil {
+		vals = append(vals, stateEntry{bui, next})
+	}
+
+	if prev == nil && next != nil {
+		self.count.Add(1)
+	} else if prev != nil && next == nil {
+		self.count.Add(-1)
+	}
+	shard.vals.Store(&vals)
+	return prev
+}
+
+func stateOf(bui *Bui) *state { return states.get(bui) }
+
+/*
+Calls the function with the given state attached to the builder, restoring
+the previous state afterwards, even on panic.
+*/
+func withState(bui *Bui, st *state, fun func(*Bui)) {
+	defer states.swap(bui, states.swap(bui, st))
+	fun(bui)
+}
+
+func (self *state) top() *frame {
+	if len(self.stack) == 0 {
+		return nil
+	}
+	return &self.stack[len(self.stack)-1]
+}
+
+func (self *state) push(val frame) { self.stack = append(self.stack, val) }
+
+func (self *state) pop() (frame, bool) {
+	if len(self.stack) == 0 {
+		return frame{}, false
+	}
+	out := self.stack[len(self.stack)-1]
+	self.stack = self.stack[:len(self.stack)-1]
+	return out, true
+}
+
+// True if anything was written by this render, including flushed output.
+func (self *state) written(bui *Bui) bool {
+	return len(*bui) > 0 || self.stream != nil && self.stream.flushes > 0
+}
+
+/*
+Called by `Bui.End` after writing the end tag. Detaches a temporary state
+attached by `Bui.Begin`, once the outermost element is closed.
+*/
+func (self *state) detach(bui *Bui) {
+	if self.auto && len(self.stack) == 0 {
+		states.swap(bui, nil)
+	}
+}
+
+// Called by `Bui.Begin` before writing the start tag.
+func (self *state) begin(bui *Bui, tag string) {
+	if self.minify() {
+		self.retract(bui, tag, false)
+	}
+
+	outer := self.dialect
+	self.dialect = outer.Sub(tag)
+
+	parent := self.top()
+	format := self.ctx.indent() != `` &&
+		(parent == nil || parent.format) &&
+		!self.dialect.foreign() &&
+		Block.Has(tag)
+
+	if format {
+		if self.written(bui) {
+			self.newline(bui, len(self.stack))
+		}
+		if parent != nil {
+			parent.blocks = true
+		}
+	}
+
+	self.push(frame{
+		tag:    tag,
+		format: format && !Verbatim.Has(tag),
+		pre:    parent != nil && parent.pre || Verbatim.Has(tag),
+		outer:  outer,
+	})
+}
+
+// Called by `Bui.Begin` after writing the start tag.
+func (self *state) opened(bui *Bui) {
+	self.edge = self.mark(bui)
+	if top := self.top(); top != nil {
+		top.open = self.edge
+		if !self.xml() && self.dialect.IsRaw(top.tag) {
+			top.raw = true
+			self.raw++
+		}
+	}
+}
+
+/*
+Called by `Bui.End` before writing the end tag. Returns true if the element
+was closed by turning its start tag into a self-closing tag, see `Ctx.Xml` and
+`DialectConf.SelfClose`. Restores the dialect of the parent.
+*/
+func (self *state) end(bui *Bui, tag string) bool {
+	if top := self.top(); top != nil && top.raw {
+		self.endRaw(bui, top)
+	}
+	if self.minify() {
+		self.retract(bui, tag, true)
+	}
+
+	val, ok := self.pop()
+	if !ok {
+		return false
+	}
+	defer func() { self.dialect = val.outer }()
+
+	if (self.xml() || self.dialect.selfClose()) && val.open == self.mark(bui) && val.open.pos > 0 {
+		*bui = append((*bui)[:val.open.pos-1], `/>`...)
+		return true
+	}
+
+	if val.blocks {
+		self.newline(bui, len(self.stack))
+	}
+	return false
+}
+
+/*
+Validates the content of a raw text element, which starts after its start tag.
+Flushing is disabled in raw text, so the content is entirely in the buffer.
+*/
+func (self *state) endRaw(bui *Bui, top *frame) {
+	val := (*bui)[top.open.pos:]
+	validRaw(self, top.tag, val)
+	self.ctx.hashRaw(top.tag, val)
+	top.raw = false
+	self.raw--
+}
+
+/*
+Name of the innermost raw text element being written, or an empty string
+outside of raw text. Nil-safe.
+*/
+func (self *state) rawTag() string {
+	if self == nil || self.raw == 0 {
+		return ``
+	}
+	for ind := len(self.stack) - 1; ind >= 0; ind-- {
+		if self.stack[ind].raw {
+			return self.stack[ind].tag
+		}
+	}
+	return ``
+}
+
+/*
+True if markup may be written at the current position, which allows to write
+finished deferred subtrees early, see `Defer`. False in raw text, XML, foreign
+content such as SVG, and elements listed in `deferOpaque`. Nil-safe.
+*/
+func (self *state) canDrain() bool {
+	if self == nil || self.raw > 0 || self.xml() || self.dialect.foreign() {
+		return false
+	}
+	for _, val := range self.stack {
+		if deferOpaque.Has(val.tag) || hasUpper(val.tag) && deferOpaque.Has(strings.ToLower(val.tag)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Names of the open elements, outermost first. Nil-safe.
+func (self *state) path() []string {
+	if self == nil || len(self.stack) == 0 {
+		return nil
+	}
+	out := make([]string, len(self.stack))
+	for ind, val := range self.stack {
+		out[ind] = val.tag
+	}
+	return out
+}
+
+// Current dialect. Nil-safe.
+func (self *state) getDialect() *Dialect {
+	if self == nil {
+		return nil
+	}
+	return self.dialect
+}
+
+// True if the output uses XML syntax. Nil-safe.
+func (self *state) xml() bool {
+	return self != nil && (self.ctx.xml() || self.dialect.isXml())
+}
+
+// True if the output is minified. Nil-safe.
+func (self *state) minify() bool {
+	return self != nil && self.ctx.minify() && !self.dialect.isXml()
+}
+
+func (self *state) newline(bui *Bui, depth int) {
+	bui.NonEscString("\n")
+	for range iter(depth) {
+		bui.NonEscString(self.ctx.Indent)
+	}
+}
@@ -0,0 +1,192 @@
+package gax
+
+import (
+	"errors"
+	"io"
+)
+
+// Default flush threshold for `Stream`, in bytes.
+const StreamLimit = 4096
+
+/*
+Streaming counterpart to `Bui`. Renders markup into an internal buffer
+(`.Buf`), periodically flushing it into the underlying writer (`.Wri`), which
+allows to begin sending a large document before it's fully rendered, while
+keeping memory usage bounded.
+
+The rendering methods `Stream.E`, `Stream.F`, `Stream.Child` have the same
+semantics as their `Bui` counterparts. Nested renderers, such as `Ren` and
+`func(*Bui)`, receive the internal buffer, and are rendered as usual. Flushing
+happens at element boundaries: whenever an element is closed and the buffer
+exceeds `.Limit`. Additional explicit flush points may be placed via
+`Stream.Flush` or `Bui.Flush`. Slow subtrees may be rendered out of order via
+`Defer`, and are written at flush points once finished.
+
+Unlike `Bui`, this must surface IO errors. The first write error is stored in
+`.Err` and returned from `Stream.Flush` and `Stream.Close`. After an error, further
+output is discarded. Rendering errors reported via `Bui.Fail`, including
+errors of `TryRen` children, are collected and returned from `Stream.Close`
+if there's no write error; by then, part of the document may have been sent.
+
+At the end of rendering, the stream must be closed via `Stream.Close`.
+
+Optional render settings may be provided via `.Ctx`; see `Ctx`.
+
+Usage:
+
+	out := gax.Stream{Wri: wri}
+	out.F(gax.Str(gax.Doctype), Page(dat))
+	err := out.Close()
+*/
+type Stream struct {
+	Wri     io.Writer
+	Limit   int
+	Buf     Bui
+	Err     error
+	Ctx     *Ctx
+	defers  defers
+	state   *state
+	errs    []error
+	flushes int
+}
+
+// Same as `Bui.E`, but for streaming.
+func (self *Stream) E(tag string, attrs Attrs, children ...any) {
+	self.run(func(bui *Bui) { bui.E(tag, attrs, children...) })
+}
+
+// Same as `Bui.F`, but for streaming.
+func (self *Stream) F(vals ...any) {
+	for _, val := range vals {
+		self.Child(val)
+	}
+}
+
+// Same as `Bui.Child`, but for streaming.
+func (self *Stream) Child(val any) {
+	self.run(func(bui *Bui) { bui.Child(val) })
+}
+
+// Shorter alias for `Stream.Child`.
+func (self *Stream) C(val any) { self.Child(val) }
+
+/*
+Appends the subtrees deferred via `Defer` which are finished so far, then
+writes the entire buffer into the underlying writer, regardless of `.Limit`.
+If the writer supports flushing, as `http.Flusher` or `bufio.Writer` do, also
+flushes the writer. Returns the first error encountered by this stream, if
+any.
+*/
+func (self *Stream) Flush() error {
+	self.run(self.drain)
+	return self.flushWri(&self.Buf)
+}
+
+// Implementation of `Stream.Flush`. Takes the buffer being rendered.
+func (self *Stream) flushWri(bui *Bui) error {
+	self.flush(bui)
+	if self.Err == nil {
+		switch wri := self.Wri.(type) {
+		case interface{ Flush() error }:
+			self.Err = wri.Flush()
+		case interface{ Flush() }:
+			wri.Flush()
+		}
+	}
+	return self.Err
+}
+
+/*
+Finishes rendering. Waits for the subtrees deferred via `Defer`, appending
+each to the document as soon as it's done, then flushes the stream via
+`Stream.Flush`. Doesn't close the underlying writer. Returns the first write
+error encountered by this stream, if any, or otherwise the rendering errors
+reported via `Bui.Fail`, combined as in `TryF`.
+*/
+func (self *Stream) Close() error {
+	if self.state != nil {
+		self.state.finish(&self.Buf)
+	}
+
+	for {
+		val := self.defers.next()
+		if val == nil {
+			break
+		}
+		self.run(func(bui *Bui) { self.defers.resolve(bui, val) })
+	}
+
+	if err := self.Flush(); err != nil {
+		return err
+	}
+	return joinErrs(self.errs)
+}
+
+func (self *Stream) run(fun func(*Bui)) {
+	if stateOf(&self.Buf) != nil {
+		fun(&self.Buf)
+		self.flushOver(&self.Buf)
+		return
+	}
+
+	if self.state == nil {
+		self.state = &state{stream: self, errs: &self.errs}
+	}
+	self.state.ctx = self.Ctx
+	if len(self.state.stack) == 0 {
+		self.state.dialect = self.Ctx.dialect()
+	}
+
+	withState(&self.Buf, self.state, func(bui *Bui) {
+		fun(bui)
+		self.flushOver(bui)
+	})
+}
+
+/*
+Writes the deferred subtrees finished so far, without waiting for others. Must
+be called during rendering, and only where markup may be written, see
+`state.canDrain`.
+*/
+func (self *Stream) drain(bui *Bui) {
+	if !stateOf(bui).canDrain() {
+		return
+	}
+	for {
+		val := self.defers.poll()
+		if val == nil {
+			return
+		}
+		self.defers.resolve(bui, val)
+	}
+}
+
+func (self *Stream) limit() int {
+	if self.Limit > 0 {
+		return self.Limit
+	}
+	return StreamLimit
+}
+
+func (self *Stream) flushOver(bui *Bui) {
+	if len(*bui) >= self.limit() && stateOf(bui).raw == 0 {
+		self.drain(bui)
+		self.flush(bui)
+	}
+}
+
+func (self *Stream) flush(bui *Bui) {
+	if len(*bui) == 0 {
+		return
+	}
+
+	if self.Err == nil {
+		if self.Wri == nil {
+			self.Err = errors.New(`[gax] can't flush stream: missing writer`)
+		} else {
+			_, self.Err = self.Wri.Write(*bui)
+		}
+	}
+	*bui = (*bui)[:0]
+	self.flushes++
+}
@@ -0,0 +1,225 @@
+package gax
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestStream_equiv(t *testing.T) {
+	doc := []any{
+		Str(Doctype),
+		E(`html`, AP(`lang`, `en`),
+			E(`head`, nil, E(`title`, nil, `test markup`)),
+			E(`body`, nil, func(b *Bui) {
+				for _, post := range mockDat.Posts {
+					b.E(`h2`, nil, E(`a`, AP(`href`, post.Path), post.Title))
+				}
+			}),
+		),
+	}
+
+	var buf strings.Builder
+	out := Stream{Wri: &buf, Limit: 64}
+	out.F(doc...)
+	must(out.Flush())
+
+	eq(t, buf.String(), F(doc...).String())
+}
+
+func TestStream_chunks(t *testing.T) {
+	var wri chunkWri
+	out := Stream{Wri: &wri, Limit: 16}
+
+	out.E(`ul`, nil, func(b *Bui) {
+		for range iter(4) {
+			b.E(`li`, nil, `one two three`)
+		}
+	})
+	eq(t, out.Buf.String(), `</ul>`)
+	must(out.Flush())
+
+	eq(t, wri.chunks, []string{
+		`<ul><li>one two three</li>`,
+		`<li>one two three</li>`,
+		`<li>one two three</li>`,
+		`<li>one two three</li>`,
+		`</ul>`,
+	})
+}
+
+func TestStream_closure(t *testing.T) {
+	var buf strings.Builder
+	out := Stream{Wri: &buf, Limit: 8}
+	E := out.E
+
+	E(`div`, nil, func() { E(`p`, nil, `nested`) }, `tail`)
+	must(out.Close())
+	eq(t, buf.String(), `<div><p>nested</p>tail</div>`)
+}
+
+func TestStream_limit(t *testing.T) {
+	var wri chunkWri
+	out := Stream{Wri: &wri}
+
+	out.E(`div`, nil, `one`)
+	out.E(`div`, nil, `two`)
+	eq(t, len(wri.chunks), 0)
+
+	must(out.Flush())
+	eq(t, wri.chunks, []string{`<div>one</div><div>two</div>`})
+}
+
+func TestBui_Flush(t *testing.T) {
+	var wri chunkWri
+	out := Stream{Wri: &wri}
+
+	out.E(`html`, nil,
+		E(`head`, nil),
+		func(b *Bui) { b.Flush() },
+		E(`body`, nil),
+	)
+	must(out.Flush())
+
+	eq(t, wri.chunks, []string{`<html><head></head>`, `<body></body></html>`})
+	eq(t, wri.flushes, 2)
+
+	var bui Bui
+	bui.E(`div`, nil, func(b *Bui) { b.Flush() })
+	eqs(t, bui, `<div></div>`)
+}
+
+func TestStream_Err(t *testing.T) {
+	fail := errors.New(`fail`)
+	wri := failWri{fail}
+	out := Stream{Wri: wri, Limit: 1}
+
+	out.E(`div`, nil, `one`)
+	eq(t, out.Err, fail)
+	eq(t, len(out.Buf), 0)
+
+	out.E(`div`, nil, `two`)
+	eq(t, len(out.Buf), 0)
+	eq(t, out.Flush(), fail)
+
+	eq(t, (&Stream{}).Flush(), nil)
+
+	out = Stream{}
+	out.E(`div`, nil)
+	eq(t, out.Flush() != nil, true)
+}
+
+type chunkWri struct {
+	chunks  []string
+	flushes int
+}
+
+func (self *chunkWri) Write(val []byte) (int, error) {
+	self.chunks = append(self.chunks, string(val))
+	return len(val), nil
+}
+
+func (self *chunkWri) Flush() { self.flushes++ }
+
+type failWri struct{ err error }
+
+func (self failWri) Write([]byte) (int, error) { return 0, self.err }
+
+func TestDefer_Bui(t *testing.T) {
+	eqs(t, F(Defer(`loading`, E(`p`, nil, `done`))), `<p>done</p>`)
+	eqs(t, F(Defer(`loading`, nil)), `loading`)
+}
+
+func TestDefer_Stream(t *testing.T) {
+	one := make(chan struct{})
+	two := make(chan struct{})
+
+	var buf strings.Builder
+	out := Stream{Wri: &buf}
+
+	out.E(`div`, nil,
+		Defer(E(`span`, nil, `loading one`), slowRen{one, E(`p`, nil, `one`)}),
+		Defer(`loading two`, slowRen{two, E(`p`, nil, `two`)}),
+		E(`p`, nil, `three`),
+	)
+	must(out.Flush())
+
+	eq(t, buf.String(), `<div><template id="gax-d1"></template><span>loading one</span><!--/gax-d1--><template id="gax-d2"></template>loading two<!--/gax-d2--><p>three</p></div>`)
+
+	buf.Reset()
+	close(two)
+	val := out.defers.next()
+	close(one)
+	out.run(func(bui *Bui) { out.defers.resolve(bui, val) })
+	must(out.Close())
+
+	eq(t, buf.String(), `<script>`+deferScript+`</script><template id="gax-r2"><p>two</p></template><script>gaxSwap(2)</script><template id="gax-r1"><p>one</p></template><script>gaxSwap(1)</script>`)
+}
+
+func TestDefer_Stream_flush(t *testing.T) {
+	var buf strings.Builder
+	out := Stream{Wri: &buf}
+
+	out.E(`div`, nil, Defer(`loading`, E(`p`, nil, `one`)))
+	waitReady(&out.defers)
+
+	out.E(`textarea`, nil, func(bui *Bui) { bui.Flush() })
+	eq(t, buf.String(), `<div><template id="gax-d1"></template>loading<!--/gax-d1--></div><textarea>`)
+
+	buf.Reset()
+	out.E(`div`, nil, func(bui *Bui) { bui.Flush() })
+	eq(t, buf.String(), `</textarea><div><script>`+deferScript+`</script><template id="gax-r1"><p>one</p></template><script>gaxSwap(1)</script>`)
+
+	buf.Reset()
+	must(out.Close())
+	eq(t, buf.String(), `</div>`)
+}
+
+func TestDefer_panic(t *testing.T) {
+	out := Stream{Wri: &strings.Builder{}}
+	out.C(Defer(nil, slowRen{nil, func(*Bui) { panic(`fail`) }}))
+
+	defer func() { eq(t, recover(), any(`fail`)) }()
+	_ = out.Close()
+	t.Fatal(`unreachable`)
+}
+
+func TestDefer_panic_flush(t *testing.T) {
+	var buf strings.Builder
+	out := Stream{Wri: &buf}
+	out.E(`div`, nil, Defer(nil, slowRen{nil, func(*Bui) { panic(`fail`) }}))
+	waitReady(&out.defers)
+
+	out.E(`p`, nil, func(bui *Bui) { bui.Flush() })
+	must(out.Flush())
+	eq(t, buf.String(), `<div><template id="gax-d1"></template><!--/gax-d1--></div><p></p>`)
+
+	defer func() { eq(t, recover(), any(`fail`)) }()
+	_ = out.Close()
+	t.Fatal(`unreachable`)
+}
+
+type slowRen struct {
+	wait chan struct{}
+	val  any
+}
+
+func (self slowRen) Render(bui *Bui) {
+	if self.wait != nil {
+		<-self.wait
+	}
+	bui.Child(self.val)
+}
+
+func waitReady(val *defers) {
+	for {
+		val.Lock()
+		ok := len(val.ready) > 0
+		val.Unlock()
+		if ok {
+			return
+		}
+		runtime.Gosched()
+	}
+}
@@ -0,0 +1,354 @@
+package gax
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	r "reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+Short for "attributes from struct". Encodes the fields of a struct, or of a
+pointer to a struct, as attributes. Useful for component "props". Symmetric
+with `Attrs.AS`, and inverse of `Attrs.Decode`. Fields are described via the
+"gax" tag:
+
+	type Props struct {
+		Id       string `gax:"id,omitempty"`
+		Disabled bool   `gax:"disabled"`
+		Expanded bool   `gax:"aria-expanded"`
+		Size     *int   `gax:"data-size"`
+		Skip     string `gax:"-"`
+	}
+
+Rules:
+
+	* Only exported fields with a "gax" tag are encoded, in declaration order.
+	  The tag "-" skips the field.
+	* Untagged embedded structs, and non-nil pointers to them, contribute their
+	  fields as if they were declared in the outer struct. Outer fields take
+	  priority over embedded fields with the same name.
+	* With the "omitempty" option, zero values are omitted. Nil pointers are
+	  always omitted; other pointers are dereferenced.
+	* `bool` is written as "true" or "false". Like other string values, this is
+	  treated as present or absent for attributes listed in `Bool` or in the
+	  current `Dialect`, and kept as-is for others such as "aria-expanded".
+	  Use `Flag` for boolean attributes not listed in `Bool`.
+	* `Flag`, `time.Time`, `url.URL`, trusted types such as `Url`, and scalars
+	  including named types are encoded as in `AV`.
+	* Types implementing `encoding.TextMarshaler`, then `fmt.Stringer`, use the
+	  respective method.
+
+Other field types cause a panic. Field metadata is computed once per type and
+cached. For nil input, returns nil.
+*/
+func AS(val any) Attrs { return Attrs(nil).AS(val) }
+
+/*
+Shortcut for appending more attributes from a struct, as if by calling `AS`.
+*/
+func (self Attrs) AS(val any) Attrs {
+	rval, ok := structValue(val, `AS`)
+	if !ok {
+		return self
+	}
+
+	for _, field := range structFieldsOf(rval.Type()) {
+		val, ok := field.get(rval)
+		if !ok || field.omit && val.IsZero() {
+			continue
+		}
+		if attr := (Attr{field.name, ``}).field(val); attr != (Attr{}) {
+			self = append(self, attr)
+		}
+	}
+	return self
+}
+
+/*
+Decodes attributes into the fields of a struct, which must be given as a
+non-nil pointer. Inverse of `AS`, mostly useful for tests. Fields whose
+attributes are missing are left unchanged. `bool` fields become false for the
+value "false", and true for any other value, including empty values of
+boolean attributes. `Flag` fields become `FlagOff` or `FlagOn` by the same
+rule. Nil pointers are allocated as needed. Types implementing
+`encoding.TextUnmarshaler` use it. Returns an error for values which can't be
+parsed, and panics for invalid input or unsupported field types.
+*/
+func (self Attrs) Decode(out any) error {
+	rval := r.ValueOf(out)
+	if rval.Kind() != r.Pointer || rval.IsNil() || rval.Elem().Kind() != r.Struct {
+		panic(AttrError{Val: out, Err: fmt.Errorf(`Attrs.Decode expects a non-nil pointer to a struct, got %T`, out)})
+	}
+	rval = rval.Elem()
+
+	for _, field := range structFieldsOf(rval.Type()) {
+		ind := self.index(field.name)
+		if ind < 0 {
+			continue
+		}
+		val, err := field.set(rval)
+		if err == nil {
+			err = decodeAttr(val, self[ind].Value())
+		}
+		if err != nil {
+			return fmt.Errorf(`[gax] failed to decode attribute %q into field %q: %w`, field.name, field.path, err)
+		}
+	}
+	return nil
+}
+
+type structField struct {
+	name  string
+	path  string
+	index []int
+	omit  bool
+}
+
+/*
+Returns the value of the field, following embedded pointers. False if any
+pointer along the way, or the field itself, is nil.
+*/
+func (self structField) get(rval r.Value) (r.Value, bool) {
+	for _, ind := range self.index {
+		if rval.Kind() == r.Pointer {
+			if rval.IsNil() {
+				return rval, false
+			}
+			rval = rval.Elem()
+		}
+		rval = rval.Field(ind)
+	}
+
+	for rval.Kind() == r.Pointer {
+		if rval.IsNil() {
+			return rval, false
+		}
+		rval = rval.Elem()
+	}
+	return rval, true
+}
+
+/*
+Returns the settable value of the field, allocating nil pointers along the
+way, including the field itself. Nil pointers to unexported embedded structs
+can't be allocated, which results in an error.
+*/
+func (self structField) set(rval r.Value) (r.Value, error) {
+	var err error
+	for _, ind := range self.index {
+		rval, err = allocPointer(rval)
+		if err != nil {
+			return rval, err
+		}
+		rval = rval.Field(ind)
+	}
+	return allocPointer(rval)
+}
+
+func allocPointer(rval r.Value) (r.Value, error) {
+	for rval.Kind() == r.Pointer {
+		if rval.IsNil() {
+			if !rval.CanSet() {
+				return rval, fmt.Errorf(`can't allocate embedded pointer to unexported type %v`, rval.Type().Elem())
+			}
+			rval.Set(r.New(rval.Type().Elem()))
+		}
+		rval = rval.Elem()
+	}
+	return rval, nil
+}
+
+var structFieldCache sync.Map
+
+func structFieldsOf(typ r.Type) []structField {
+	val, ok := structFieldCache.Load(typ)
+	if ok {
+		return val.([]structField)
+	}
+	val, _ = structFieldCache.LoadOrStore(typ, structFieldsUncached(typ))
+	return val.([]structField)
+}
+
+func structFieldsUncached(typ r.Type) []structField {
+	var out []structField
+	appendStructFields(&out, typ, nil, ``)
+
+	// Outer fields take priority over embedded fields with the same name.
+	var dedup []structField
+	for _, field := range out {
+		ind := structFieldIndex(dedup, field.name)
+		if ind < 0 {
+			dedup = append(dedup, field)
+		} else if len(field.index) < len(dedup[ind].index) {
+			dedup[ind] = field
+		}
+	}
+	return dedup
+}
+
+func appendStructFields(out *[]structField, typ r.Type, index []int, prefix string) {
+	for ind := 0; ind < typ.NumField(); ind++ {
+		field := typ.Field(ind)
+		tag, tagged := field.Tag.Lookup(`gax`)
+		path := append(index[:len(index):len(index)], ind)
+
+		if field.Anonymous && !tagged {
+			embed := field.Type
+			if embed.Kind() == r.Pointer {
+				embed = embed.Elem()
+			}
+			if embed.Kind() == r.Struct {
+				appendStructFields(out, embed, path, prefix+field.Name+`.`)
+				continue
+			}
+		}
+
+		if !tagged || tag == `-` || !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, `,`)
+		if name == `` {
+			panic(AttrError{Val: typ, Err: fmt.Errorf(`missing attribute name in tag of field %q of %v`, field.Name, typ)})
+		}
+
+		*out = append(*out, structField{
+			name:  name,
+			path:  prefix + field.Name,
+			index: path,
+			omit:  hasString(strings.Split(opts, `,`), `omitempty`),
+		})
+	}
+}
+
+func structFieldIndex(vals []structField, name string) int {
+	for ind, val := range vals {
+		if val.name == name {
+			return ind
+		}
+	}
+	return -1
+}
+
+func structValue(val any, name string) (r.Value, bool) {
+	rval := r.ValueOf(val)
+	for rval.Kind() == r.Pointer {
+		if rval.IsNil() {
+			return rval, false
+		}
+		rval = rval.Elem()
+	}
+
+	if !rval.IsValid() {
+		return rval, false
+	}
+	if rval.Kind() != r.Struct {
+		panic(AttrError{Val: val, Err: fmt.Errorf(`%v expects a struct or a pointer to a struct, got %T`, name, val)})
+	}
+
+	// Allows methods with pointer receivers, such as `MarshalText`.
+	if !rval.CanAddr() {
+		ptr := r.New(rval.Type())
+		ptr.Elem().Set(rval)
+		rval = ptr.Elem()
+	}
+	return rval, true
+}
+
+// Returns a version with the value of the given struct field. See `AS`.
+func (self Attr) field(rval r.Value) Attr {
+	val := rval.Interface()
+
+	switch val := val.(type) {
+	case Flag, time.Time, url.URL:
+		return attrOf(self.Name(), val)
+	case encoding.TextMarshaler:
+		return self.text(val)
+	}
+
+	if rval.CanAddr() {
+		if val, ok := rval.Addr().Interface().(encoding.TextMarshaler); ok {
+			return self.text(val)
+		}
+	}
+
+	if val, ok := val.(fmt.Stringer); ok {
+		return self.Set(val.String())
+	}
+	if out, ok := self.scalar(val); ok {
+		return out
+	}
+	panic(self.unsupported(val))
+}
+
+func (self Attr) text(val encoding.TextMarshaler) Attr {
+	out, err := val.MarshalText()
+	if err != nil {
+		panic(self.encodeErr(val, err))
+	}
+	return self.Set(string(out))
+}
+
+// Decodes an attribute value into a settable field. See `Attrs.Decode`.
+func decodeAttr(rval r.Value, src string) error {
+	switch rval.Interface().(type) {
+	case Flag:
+		rval.Set(r.ValueOf(FlagOf(src != `false`)))
+		return nil
+	case time.Time:
+		val, err := time.Parse(time.RFC3339Nano, src)
+		if err == nil {
+			rval.Set(r.ValueOf(val))
+		}
+		return err
+	case url.URL:
+		val, err := url.Parse(src)
+		if err == nil {
+			rval.Set(r.ValueOf(*val))
+		}
+		return err
+	}
+
+	if val, ok := rval.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return val.UnmarshalText([]byte(src))
+	}
+
+	switch rval.Kind() {
+	case r.String:
+		rval.SetString(src)
+		return nil
+
+	case r.Bool:
+		rval.SetBool(src != `false`)
+		return nil
+
+	case r.Int, r.Int8, r.Int16, r.Int32, r.Int64:
+		val, err := strconv.ParseInt(src, 10, rval.Type().Bits())
+		if err == nil {
+			rval.SetInt(val)
+		}
+		return err
+
+	case r.Uint, r.Uint8, r.Uint16, r.Uint32, r.Uint64:
+		val, err := strconv.ParseUint(src, 10, rval.Type().Bits())
+		if err == nil {
+			rval.SetUint(val)
+		}
+		return err
+
+	case r.Float32, r.Float64:
+		val, err := strconv.ParseFloat(src, rval.Type().Bits())
+		if err == nil {
+			rval.SetFloat(val)
+		}
+		return err
+
+	default:
+		panic(AttrError{Val: rval.Type(), Err: fmt.Errorf(`unsupported field type %v for attribute decoding`, rval.Type())})
+	}
+}
@@ -0,0 +1,153 @@
+package gax
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type structBase struct {
+	Id    string `gax:"id,omitempty"`
+	Class string `gax:"class,omitempty"`
+}
+
+type structExtra struct {
+	Title string `gax:"title"`
+}
+
+type structText struct{ val string }
+
+func (self *structText) MarshalText() ([]byte, error) {
+	return []byte(strings.ToUpper(self.val)), nil
+}
+
+func (self *structText) UnmarshalText(src []byte) error {
+	self.val = strings.ToLower(string(src))
+	return nil
+}
+
+type structProps struct {
+	structBase
+	*structExtra
+	Class    string     `gax:"class"`
+	Disabled bool       `gax:"disabled"`
+	Hidden   bool       `gax:"hidden,omitempty"`
+	Expanded bool       `gax:"aria-expanded"`
+	Open     Flag       `gax:"open"`
+	Size     *int       `gax:"data-size"`
+	Width    float64    `gax:"width,omitempty"`
+	Kind     dataKind   `gax:"data-kind,omitempty"`
+	Href     *url.URL   `gax:"href"`
+	Time     time.Time  `gax:"datetime,omitempty"`
+	Text     structText `gax:"data-text,omitempty"`
+	Cls      Class      `gax:"data-cls,omitempty"`
+	Skip     string     `gax:"-"`
+	Untagged string
+	private  string     `gax:"private"`
+}
+
+func TestAS(t *testing.T) {
+	eq(t, AS(nil), nil)
+	eq(t, AS((*structProps)(nil)), nil)
+	eq(t, AS(structProps{}), AP(`class`, ``, `disabled`, `false`, `aria-expanded`, `false`))
+	eq(t, AP(`one`, `two`).AS(structBase{Id: `three`}), AP(`one`, `two`, `id`, `three`))
+
+	size := 10
+	props := structProps{
+		structBase:  structBase{Id: `one`, Class: `ignored`},
+		structExtra: &structExtra{Title: `two`},
+		Class:       `three`,
+		Disabled:    true,
+		Expanded:    true,
+		Open:        FlagOff,
+		Size:        &size,
+		Width:       1.5,
+		Kind:        `four`,
+		Href:        &url.URL{Path: `/five`},
+		Time:        time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Text:        structText{`six`},
+		Cls:         Class{`seven`},
+		Skip:        `skip`,
+		Untagged:    `skip`,
+		private:     `skip`,
+	}
+
+	eqs(
+		t,
+		AS(&props),
+		` id="one" class="three" title="two" disabled="" aria-expanded="true" data-size="10" width="1.5" data-kind="four" href="/five" datetime="2024-01-02T03:04:05Z" data-text="SIX" data-cls="seven"`,
+	)
+	eqs(t, AS(props), AS(&props).String())
+}
+
+func TestAttrs_Decode(t *testing.T) {
+	size := 10
+	src := structProps{
+		structBase:  structBase{Id: `one`},
+		structExtra: &structExtra{Title: `two`},
+		Class:       `three`,
+		Disabled:    true,
+		Open:        FlagOn,
+		Size:        &size,
+		Width:       1.5,
+		Kind:        `four`,
+		Href:        &url.URL{Path: `/five`},
+		Time:        time.Date(2024, 1, 2, 3, 4, 5, 600000000, time.UTC),
+		Text:        structText{`six`},
+	}
+
+	out := structProps{structExtra: &structExtra{}}
+	must(AS(&src).Decode(&out))
+	eq(t, out, src)
+
+	out = structProps{Skip: `seven`}
+	must(AP(`disabled`, ``, `aria-expanded`, `false`, `open`, `open`, `skip`, `eight`).Decode(&out))
+	eq(t, out, structProps{Disabled: true, Open: FlagOn, Skip: `seven`})
+
+	eq(
+		t,
+		fmt.Sprint(AP(`data-size`, `one`).Decode(&out)),
+		`[gax] failed to decode attribute "data-size" into field "Size": strconv.ParseInt: parsing "one": invalid syntax`,
+	)
+	eq(
+		t,
+		fmt.Sprint(AP(`title`, `one`).Decode(&out)),
+		`[gax] failed to decode attribute "title" into field "structExtra.Title": can't allocate embedded pointer to unexported type gax.structExtra`,
+	)
+
+	out.structExtra = &structExtra{}
+	eq(
+		t,
+		fmt.Sprint(AP(`title`, `one`, `width`, `two`).Decode(&out)),
+		`[gax] failed to decode attribute "width" into field "Width": strconv.ParseFloat: parsing "two": invalid syntax`,
+	)
+	eq(t, out.structExtra, &structExtra{Title: `one`})
+}
+
+func TestAS_panic(t *testing.T) {
+	test := func(msg string, fun func()) {
+		t.Helper()
+		defer func() {
+			t.Helper()
+			eq(t, fmt.Sprint(recover()), msg)
+		}()
+		fun()
+	}
+
+	test(`[gax] AS expects a struct or a pointer to a struct, got string`, func() { AS(`one`) })
+	test(`[gax] missing attribute name in tag of field "One" of struct { One string "gax:\",omitempty\"" }`, func() {
+		AS(struct {
+			One string `gax:",omitempty"`
+		}{})
+	})
+	test(`[gax] unsupported value type []int for attribute "one"`, func() {
+		AS(struct {
+			One []int `gax:"one"`
+		}{})
+	})
+	test(`[gax] Attrs.Decode expects a non-nil pointer to a struct, got gax.structBase`, func() {
+		_ = AP().Decode(structBase{})
+	})
+}
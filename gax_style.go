@@ -0,0 +1,292 @@
+package gax
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Builder for the "style" attribute: ordered list of CSS property-value pairs.
+Usually created as a literal and rendered via `Style.Attr`:
+
+	E(`div`, A(Style{{`color`, `red`}, {`width`, width}}.Attr()))
+
+Property names are validated when rendering, and invalid names cause a panic.
+Values are checked for content which could escape the declaration or run
+code: stray ";", "{", "}" or unbalanced quotes and parentheses, comments,
+backslash escapes outside strings, `expression(...)`, and `url(...)` with an
+unsafe scheme, as described in `UrlSchemes`. Declarations with such values,
+or with empty values, are omitted. The properties "behavior" and
+"-moz-binding", which load code in legacy browsers, are always omitted.
+
+Properties are compared case-insensitively, except for custom properties such
+as "--one". Zero pairs are ignored. Also implements `fmt.Stringer`, and may be
+passed as a value to `AV`.
+*/
+type Style [][2]string
+
+/*
+Returns the value of the last declaration of the given property, which is the
+one applied by CSS, or an empty string if there's no such declaration.
+*/
+func (self Style) Get(key string) string {
+	key = styleKey(key)
+	for ind := len(self) - 1; ind >= 0; ind-- {
+		if self[ind][0] != `` && styleKey(self[ind][0]) == key {
+			return self[ind][1]
+		}
+	}
+	return ``
+}
+
+// True if there's a declaration of the given property.
+func (self Style) Has(key string) bool {
+	key = styleKey(key)
+	for _, val := range self {
+		if val[0] != `` && styleKey(val[0]) == key {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Returns a version where previous declarations of the given property are
+removed, and the given declaration is appended. Moving the declaration to the
+end ensures that it overrides related shorthands, such as "margin" for
+"margin-top". As a special case, if the property is empty, returns self as-is.
+*/
+func (self Style) Set(key, val string) Style {
+	if key == `` {
+		return self
+	}
+	return append(self.Del(key), [2]string{key, val})
+}
+
+/*
+Returns a version without any declarations of the given property. If there
+are no such declarations, returns self as-is. Otherwise returns a new slice,
+without modifying the original.
+*/
+func (self Style) Del(key string) Style {
+	if !self.Has(key) {
+		return self
+	}
+
+	key = styleKey(key)
+	out := make(Style, 0, len(self))
+	for _, val := range self {
+		if val[0] == `` || styleKey(val[0]) != key {
+			out = append(out, val)
+		}
+	}
+	return out
+}
+
+/*
+Returns a combination of self and the given style, as if by calling
+`Style.Set` for each declaration of the given style. Never modifies either
+input.
+*/
+func (self Style) Merge(vals Style) Style {
+	out := make(Style, len(self), len(self)+len(vals))
+	copy(out, self)
+
+	for _, val := range vals {
+		out = out.Set(val[0], val[1])
+	}
+	return out
+}
+
+/*
+Returns the "style" attribute with the rendered declarations. If there are no
+declarations to render, returns `Attr{}`, which is ignored during encoding.
+*/
+func (self Style) Attr() Attr {
+	val := self.String()
+	if val == `` {
+		return Attr{}
+	}
+	return Attr{`style`, val}
+}
+
+/*
+Renders the declarations as inline CSS, separated with "; ". See `Style` for
+the rules.
+*/
+func (self Style) String() string {
+	var buf []byte
+
+	for _, pair := range self {
+		key, val := pair[0], strings.TrimSpace(pair[1])
+		if key == `` {
+			continue
+		}
+		if !isStyleProp(key) {
+			panic(AttrError{`style`, key, fmt.Errorf(`invalid CSS property name %q`, key)})
+		}
+		if styleUnsafe.Has(styleKey(key)) || !isStyleValueSafe(val) {
+			continue
+		}
+
+		if len(buf) > 0 {
+			buf = append(buf, `; `...)
+		}
+		buf = append(buf, key...)
+		buf = append(buf, `: `...)
+		buf = append(buf, val...)
+	}
+	return string(buf)
+}
+
+// Properties which load code in legacy browsers.
+var styleUnsafe = newStringSet(`behavior`, `-moz-binding`)
+
+// Normalized property name: lowercased unless it's a custom property.
+func styleKey(key string) string {
+	if strings.HasPrefix(key, `--`) || !hasUpper(key) {
+		return key
+	}
+	return strings.ToLower(key)
+}
+
+/*
+True if the input is a valid CSS property name: an identifier, or a custom
+property which starts with "--". Reference:
+
+	https://www.w3.org/TR/css-syntax-3/#ident-token-diagram
+*/
+func isStyleProp(val string) bool {
+	if strings.HasPrefix(val, `--`) {
+		val = val[2:]
+		if val == `` {
+			return false
+		}
+	} else {
+		val = strings.TrimPrefix(val, `-`)
+		if val == `` || !isStyleNameStart(val[0]) {
+			return false
+		}
+	}
+
+	for ind := 0; ind < len(val); ind++ {
+		if !isStyleNameChar(val[ind]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isStyleNameStart(char byte) bool {
+	return isAsciiLetter(char) || char == '_' || char >= 0x80
+}
+
+func isStyleNameChar(char byte) bool {
+	return isStyleNameStart(char) || char == '-' || (char >= '0' && char <= '9')
+}
+
+/*
+True if the input may be used as a CSS declaration value without escaping the
+declaration or running code. See `Style` for the rules.
+*/
+func isStyleValueSafe(val string) bool {
+	if val == `` {
+		return false
+	}
+
+	depth := 0
+	for ind := 0; ind < len(val); ind++ {
+		char := val[ind]
+
+		switch {
+		case char == '"' || char == '\'':
+			end := styleStringEnd(val, ind)
+			if end < 0 {
+				return false
+			}
+			ind = end - 1
+
+		case char == '\\' || char == ';' || char == '{' || char == '}':
+			return false
+
+		case char == '/' && strings.HasPrefix(val[ind:], `/*`):
+			return false
+
+		case char == '(':
+			switch strings.ToLower(styleFuncName(val[:ind])) {
+			case `expression`:
+				return false
+			case `url`:
+				end := styleUrlEnd(val, ind+1)
+				if end < 0 {
+					return false
+				}
+				ind = end - 1
+			default:
+				depth++
+			}
+
+		case char == ')':
+			if depth == 0 {
+				return false
+			}
+			depth--
+		}
+	}
+	return depth == 0
+}
+
+/*
+Takes the index of an opening quote, and returns the index after the closing
+quote, or -1 if the string is unterminated or contains a newline.
+*/
+func styleStringEnd(val string, start int) int {
+	quote := val[start]
+	for ind := start + 1; ind < len(val); ind++ {
+		switch val[ind] {
+		case quote:
+			return ind + 1
+		case '\\':
+			ind++
+		case '\n', '\r', '\f':
+			return -1
+		}
+	}
+	return -1
+}
+
+/*
+Takes the index after "url(", and returns the index after the closing
+parenthesis, or -1 if the URL is malformed, contains escapes, or is unsafe.
+*/
+func styleUrlEnd(val string, start int) int {
+	end := strings.IndexByte(val[start:], ')')
+	if end < 0 {
+		return -1
+	}
+	end += start
+
+	src := strings.TrimSpace(val[start:end])
+	if len(src) > 0 && (src[0] == '"' || src[0] == '\'') {
+		if styleStringEnd(src, 0) != len(src) {
+			return -1
+		}
+		src = src[1 : len(src)-1]
+	} else if strings.ContainsAny(src, " \t\n\r\f") {
+		return -1
+	}
+
+	if strings.ContainsAny(src, "\\\"'()") || !isUrlSafe(src) {
+		return -1
+	}
+	return end + 1
+}
+
+// Name of the CSS function which ends at the end of the input, if any.
+func styleFuncName(val string) string {
+	ind := len(val)
+	for ind > 0 && isStyleNameChar(val[ind-1]) {
+		ind--
+	}
+	return val[ind:]
+}
@@ -0,0 +1,112 @@
+package gax
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStyle(t *testing.T) {
+	test := func(src Style, exp string) {
+		t.Helper()
+		eq(t, src.String(), exp)
+	}
+
+	test(nil, ``)
+	test(Style{{}, {`color`, ``}, {`color`, ` `}}, ``)
+	test(Style{{`color`, `red`}}, `color: red`)
+	test(Style{{`color`, ` red `}, {`Margin-Top`, `0`}, {`--one`, `1px`}, {`-webkit-line-clamp`, `2`}}, `color: red; Margin-Top: 0; --one: 1px; -webkit-line-clamp: 2`)
+	test(Style{{`font-family`, `"Open Sans", sans-serif`}}, `font-family: "Open Sans", sans-serif`)
+	test(Style{{`content`, `"one; two } \"three\""`}}, `content: "one; two } \"three\""`)
+	test(Style{{`width`, `calc(100% - var(--one, 10px))`}}, `width: calc(100% - var(--one, 10px))`)
+	test(Style{{`background`, `url(/one.png) no-repeat`}}, `background: url(/one.png) no-repeat`)
+	test(Style{{`background`, `url( "https://example.com/one.png" )`}}, `background: url( "https://example.com/one.png" )`)
+	test(Style{{`color`, `red !important`}}, `color: red !important`)
+}
+
+func TestStyle_unsafe(t *testing.T) {
+	test := func(val string) {
+		t.Helper()
+		eq(t, Style{{`one`, `two`}, {`background`, val}}.String(), `one: two`)
+	}
+
+	test(`red; position: fixed`)
+	test(`red}body{color: red`)
+	test(`red{`)
+	test(`"red`)
+	test(`'red`)
+	test("\"one\ntwo\"")
+	test(`calc(1px`)
+	test(`red)`)
+	test(`red /* one */`)
+	test(`\65xpression(alert(1))`)
+	test(`expression(alert(1))`)
+	test(`EXPRESSION(alert(1))`)
+	test(`url(javascript:alert(1))`)
+	test(`URL( 'javascript:alert(1)' )`)
+	test(`url("java\73cript:alert(1)")`)
+	test(`url(one two)`)
+	test(`url("one`)
+	test(`url(one`)
+
+	eq(t, Style{{`behavior`, `url(one.htc)`}, {`-MOZ-binding`, `url(one.xml)`}}.String(), ``)
+}
+
+func TestStyle_panic(t *testing.T) {
+	test := func(key string) {
+		t.Helper()
+		defer func() {
+			t.Helper()
+			eq(t, fmt.Sprint(recover()), fmt.Sprintf(`[gax] invalid CSS property name %q`, key))
+		}()
+		_ = Style{{key, `one`}}.String()
+	}
+
+	test(`-`)
+	test(`--`)
+	test(`1one`)
+	test(`-1one`)
+	test(`one two`)
+	test(`one:two`)
+	test(`one;two`)
+	test(`"one"`)
+}
+
+func TestStyle_Set(t *testing.T) {
+	src := Style{{`margin-top`, `1px`}, {`color`, `red`}}
+
+	eq(t, src.Set(``, `one`), src)
+	eq(t, src.Set(`margin`, `0`), Style{{`margin-top`, `1px`}, {`color`, `red`}, {`margin`, `0`}})
+	eq(t, src.Set(`MARGIN-TOP`, `2px`), Style{{`color`, `red`}, {`MARGIN-TOP`, `2px`}})
+	eq(t, src.Del(`Color`), Style{{`margin-top`, `1px`}})
+	eq(t, src.Del(`one`), src)
+	eq(t, src, Style{{`margin-top`, `1px`}, {`color`, `red`}})
+
+	eq(t, src.Get(`color`), `red`)
+	eq(t, src.Get(`COLOR`), `red`)
+	eq(t, src.Get(`one`), ``)
+	eq(t, src.Has(`margin-top`), true)
+	eq(t, src.Has(`margin`), false)
+	eq(t, Style{{`--one`, `1`}}.Has(`--ONE`), false)
+	eq(t, Style{{`color`, `red`}, {`color`, `blue`}}.Get(`color`), `blue`)
+}
+
+func TestStyle_Merge(t *testing.T) {
+	src := Style{{`margin-top`, `1px`}, {`margin`, `0`}, {`color`, `red`}}
+
+	eq(t, src.Merge(nil), src)
+	eq(
+		t,
+		src.Merge(Style{{`margin-top`, `2px`}, {`width`, `1px`}}),
+		Style{{`margin`, `0`}, {`color`, `red`}, {`margin-top`, `2px`}, {`width`, `1px`}},
+	)
+	eq(t, src, Style{{`margin-top`, `1px`}, {`margin`, `0`}, {`color`, `red`}})
+}
+
+func TestStyle_Attr(t *testing.T) {
+	eq(t, Style{}.Attr(), Attr{})
+	eq(t, Style{{`color`, `url(javascript:alert(1))`}}.Attr(), Attr{})
+	eq(t, Style{{`color`, `red`}}.Attr(), Attr{`style`, `color: red`})
+
+	eqs(t, E(`div`, A(Style{{`font-family`, `"one" & 'two'`}}.Attr())), `<div style="font-family: &quot;one&quot; &amp; 'two'"></div>`)
+	eqs(t, E(`div`, AV(`style`, Style{{`color`, `red`}})), `<div style="color: red"></div>`)
+}
@@ -416,6 +416,73 @@ func TestAttrs_Add(t *testing.T) {
 	)
 }
 
+func TestAttrs_Get(t *testing.T) {
+	eq(t, AP().Get(``), ``)
+	eq(t, AP().Get(`one`), ``)
+	eq(t, AP(`one`, `two`).Get(``), ``)
+	eq(t, AP(`one`, `two`).Get(`one`), `two`)
+	eq(t, AP(`one`, `two`).Get(`ONE`), ``)
+	eq(t, AP(`one`, `two`, `one`, `three`).Get(`one`), `two`)
+	eq(t, A(Attr{}, FlagOff.Attr(`one`)).Get(`one`), `false`)
+	eq(t, AV(AttrName(`one`), Url(`/two`)).Get(`one`), `/two`)
+}
+
+func TestAttrs_Has(t *testing.T) {
+	eq(t, AP().Has(``), false)
+	eq(t, AP().Has(`one`), false)
+	eq(t, AP(`one`, ``).Has(``), false)
+	eq(t, AP(`one`, ``).Has(`one`), true)
+	eq(t, AP(`one`, `two`).Has(`two`), false)
+	eq(t, A(FlagOff.Attr(`one`)).Has(`one`), true)
+	eq(t, A(FlagUnset.Attr(`one`)).Has(`one`), false)
+}
+
+func TestAttrs_Del(t *testing.T) {
+	eq(t, AP().Del(`one`), nil)
+	eq(t, AP(`one`, `two`).Del(``), AP(`one`, `two`))
+	eq(t, AP(`one`, `two`).Del(`two`), AP(`one`, `two`))
+	eq(t, AP(`one`, `two`).Del(`one`), Attrs{})
+
+	src := AP(`one`, `two`, `three`, `four`, `one`, `five`)
+	eq(t, src.Del(`one`), AP(`three`, `four`))
+	eq(t, src.Del(`three`), AP(`one`, `two`, `one`, `five`))
+	eq(t, src, AP(`one`, `two`, `three`, `four`, `one`, `five`))
+}
+
+func TestAttrs_Merge(t *testing.T) {
+	eq(t, AP().Merge(nil), nil)
+	eq(t, AP(`one`, `two`).Merge(nil), AP(`one`, `two`))
+	eq(t, AP().Merge(AP(`one`, `two`)), AP(`one`, `two`))
+	eq(t, AP(`one`, `two`).Merge(AP(`one`, `three`)), AP(`one`, `three`))
+	eq(t, AP(`one`, `two`).Merge(AP(`three`, `four`)), AP(`one`, `two`, `three`, `four`))
+	eq(t, AP(`one`, `two`).Merge(A(Attr{}, FlagUnset.Attr(`one`))), AP(`one`, `two`))
+
+	src := AP(`class`, `one two`, `style`, `color: red; margin: 0`, `onclick`, `one()`, `id`, `one`)
+
+	eq(
+		t,
+		src.Merge(AP(`class`, ` two  three `, `style`, `COLOR: blue`, `onclick`, `two()`, `title`, `one`)),
+		AP(`class`, `one two three`, `style`, `margin: 0; COLOR: blue`, `onclick`, `two()`, `id`, `one`, `title`, `one`),
+	)
+	eq(t, src, AP(`class`, `one two`, `style`, `color: red; margin: 0`, `onclick`, `one()`, `id`, `one`))
+
+	eqs(
+		t,
+		A(FlagOn.Attr(`disabled`), Attr{`hidden`, ``}).Merge(A(FlagOff.Attr(`disabled`), FlagOn.Attr(`hidden`))),
+		` hidden=""`,
+	)
+}
+
+func TestElem_AttrMerge(t *testing.T) {
+	elem := E(`div`, AP(`class`, `one`), `chi`)
+
+	eq(t, elem.AttrGet(`class`), `one`)
+	eq(t, elem.AttrHas(`class`), true)
+	eq(t, elem.AttrDel(`class`), Elem{`div`, Attrs{}, []any{`chi`}})
+	eq(t, elem.AttrMerge(AP(`class`, `two one`)), Elem{`div`, AP(`class`, `one two`), []any{`chi`}})
+	eq(t, elem, Elem{`div`, AP(`class`, `one`), []any{`chi`}})
+}
+
 func TestElem_AttrSet(t *testing.T) {
 	eq(t,
 		E(`div`, nil).AttrSet(``, ``),
@@ -522,6 +589,18 @@ func TestElem_GoString(t *testing.T) {
 	)
 }
 
+func TestFrag_GoString(t *testing.T) {
+	eq(t, fmt.Sprintf(`%#v`, Frag(nil)), `Frag(nil)`)
+	eq(t, fmt.Sprintf(`%#v`, Frag{}), `Frag{}`)
+	eq(t,
+		fmt.Sprintf(
+			`%#v`,
+			Frag{Str(Doctype), Comment(` one `), E(`two`, nil, "three\n"), Str(`<four>`)},
+		),
+		"Frag{Str(Doctype), Comment(` one `), E(`two`, nil, \"three\\n\"), Str(`<four>`)}",
+	)
+}
+
 func TestA_GoString(t *testing.T) {
 	eq(t,
 		fmt.Sprintf(
@@ -608,3 +687,92 @@ func tryInt(_ int, err error) {
 		panic(err)
 	}
 }
+
+func TestBui_E_raw(t *testing.T) {
+	test := func(tag string, exp string, children ...any) {
+		t.Helper()
+		var bui Bui
+		bui.E(tag, nil, children...)
+		eqs(t, bui, exp)
+	}
+
+	test(`script`, `<script>if (a && b < c) {}</script>`, `if (a && b < c) {}`)
+	test(`script`, `<script>let one = "<two>"</script>`, []byte(`let one = "<two>"`))
+	test(`script`, `<script>one 10 true</script>`, `one `, 10, []any{` `, true})
+	test(`script`, `<script>one two</script>`, Str(`one `), Bui(`two`))
+	test(`script`, `<script>one</script>`, func(b *Bui) { b.NonEscString(`one`) })
+	test(`script`, `<script></script>`, nil, (*string)(nil))
+	test(`script`, `<script>let a = "</scrip"</script>`, `let a = "</scrip"`)
+	test(`script`, `<script>"<\/script>"</script>`, `"<\/script>"`)
+	test(`style`, `<style>a > b {content: "</script>"}</style>`, `a > b {content: "</script>"}`)
+	test(`SCRIPT`, `<SCRIPT>a && b</SCRIPT>`, `a && b`)
+	test(`script`, `<script>if (a <!--b) {}</script>`, `if (a <!--b) {}`)
+	test(`script`, `<script>"<!-- <script> -->"</script>`, `"<!-- <script> -->"`)
+	test(`script`, `<script>"<!-- --> <script>"</script>`, `"<!-- --> <script>"`)
+	test(`script`, `<script>"<!--><script>"</script>`, `"<!--><script>"`)
+	test(`style`, `<style><!-- <script> --></style>`, `<!-- <script> -->`)
+	test(`div`, `<div>a &amp;&amp; b</div>`, `a && b`)
+
+	fail := func(tag, msg string, children ...any) {
+		t.Helper()
+		defer func() {
+			t.Helper()
+			eq(t, fmt.Sprint(recover()), msg)
+		}()
+		var bui Bui
+		bui.E(tag, nil, children...)
+	}
+
+	fail(`script`, `[gax] can't embed "</script" in raw text element "script" at "script"`, `"</script>"`)
+	fail(`script`, `[gax] can't embed "</ScRiPt" in raw text element "script" at "script"`, `"</ScRiPt>"`)
+	fail(`script`, `[gax] can't embed "</script" in raw text element "script" at "script"`, `"</scr`, `ipt>"`)
+	fail(`script`, `[gax] can't embed "</script" in raw text element "script" at "script"`, Str(`</script>`))
+	fail(`script`, `[gax] can't embed "<!--" in raw text element "script" at "script"`, `<!-- <script>`)
+	fail(`script`, `[gax] can't embed "<!--" in raw text element "script" at "script"`, `"<!--<SCRIPT/>"`)
+	fail(`style`, `[gax] can't embed "</style" in raw text element "style" at "style"`, `</style>`)
+	fail(`script`, `[gax] can't render func(int) at "script"`, func(int) {})
+}
+
+func TestElem_raw(t *testing.T) {
+	eqs(t, E(`script`, nil, `a && b`), `<script>a && b</script>`)
+}
+
+func TestBui_Begin_raw(t *testing.T) {
+	eqs(t, E(`script`, nil, func(b *Bui) { b.T(`a && b`) }), `<script>a && b</script>`)
+
+	manual := func(b *Bui) {
+		b.Begin(`script`, nil)
+		b.Child(`a && b`)
+		b.T(` && c`)
+		b.End(`script`)
+	}
+	eqs(t, (&Ctx{}).F(manual), `<script>a && b && c</script>`)
+
+	var bui Bui
+	manual(&bui)
+	eqs(t, bui, `<script>a && b && c</script>`)
+	eq(t, stateOf(&bui), nil)
+
+	bui = nil
+	E := bui.E
+	E(`div`, nil, func() { E(`script`, nil, func() { bui.T(`a && b`) }, `;c`) })
+	eqs(t, bui, `<div><script>a && b;c</script></div>`)
+	eq(t, stateOf(&bui), nil)
+
+	bui = nil
+	err := bui.TryE(`div`, nil, func() {
+		bui.Begin(`script`, nil)
+		bui.T(`</script>`)
+		bui.End(`script`)
+	})
+	eq(t, err.Error(), `[gax] can't embed "</script" in raw text element "script" at "script"`)
+	eqs(t, bui, ``)
+	eq(t, stateOf(&bui), nil)
+
+	_, err = (&Ctx{}).TryF(func(b *Bui) {
+		b.Begin(`script`, nil)
+		b.T(`"</script>"`)
+		b.End(`script`)
+	})
+	eq(t, err.Error(), `[gax] can't embed "</script" in raw text element "script" at "script"`)
+}
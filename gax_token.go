@@ -0,0 +1,385 @@
+package gax
+
+import (
+	"html"
+	"strings"
+)
+
+type tokenType byte
+
+const (
+	tokText tokenType = iota + 1
+	tokStart
+	tokEnd
+	tokComment
+	tokDoctype
+)
+
+/*
+Single token produced by `tokenizer`. For tags, `.name` is lowercased. For text
+and attribute values, character references are already decoded.
+*/
+type token struct {
+	typ   tokenType
+	name  string
+	attrs Attrs
+	data  string
+	self  bool
+}
+
+/*
+Elements whose content is not parsed as markup. Content of RCDATA elements
+may contain character references, while content of raw text elements may not.
+Reference:
+
+	https://html.spec.whatwg.org/multipage/parsing.html#parsing-html-fragments
+*/
+var (
+	tokRawText = newStringSet(
+		`iframe`, `noembed`, `noframes`, `noscript`, `script`, `style`, `xmp`,
+	)
+	tokRcdata = newStringSet(`textarea`, `title`)
+)
+
+/*
+Simple HTML tokenizer guided by the HTML5 specification. Not fully compliant,
+but handles the cases relevant for safety and structure: raw text and RCDATA
+elements, comments including bogus ones, doctypes, character references,
+duplicate attributes, quoted and unquoted attribute values, and tags which
+aren't closed before the end of input. Reference:
+
+	https://html.spec.whatwg.org/multipage/parsing.html#tokenization
+
+Unlike a browser, this switches into raw text or RCDATA mode by itself after
+the corresponding start tags. The only feedback from a tree builder is
+`.foreign`, which indicates foreign content such as SVG, where such elements
+are parsed normally, and CDATA sections are allowed.
+*/
+type tokenizer struct {
+	src     string
+	pos     int
+	raw     string
+	rcdata  bool
+	foreign bool
+}
+
+func (self *tokenizer) next() (token, bool) {
+	for self.pos < len(self.src) {
+		if self.raw != `` {
+			if tok, ok := self.rawText(); ok {
+				return tok, true
+			}
+			continue
+		}
+
+		if isMarkupStart(self.src[self.pos:]) {
+			if tok, ok := self.markup(); ok {
+				return tok, true
+			}
+			continue
+		}
+		return self.text(), true
+	}
+	return token{}, false
+}
+
+func (self *tokenizer) text() token {
+	src := self.src
+	start := self.pos
+	ind := start + 1
+
+	for ind < len(src) {
+		next := strings.IndexByte(src[ind:], '<')
+		if next < 0 {
+			ind = len(src)
+			break
+		}
+		ind += next
+		if isMarkupStart(src[ind:]) {
+			break
+		}
+		ind++
+	}
+
+	self.pos = ind
+	return token{typ: tokText, data: unescape(src[start:ind])}
+}
+
+/*
+Consumes the content of a raw text or RCDATA element, up to its end tag, which
+is then tokenized normally. Returns false if the content is empty.
+*/
+func (self *tokenizer) rawText() (token, bool) {
+	src := self.src
+	start := self.pos
+	end := len(src)
+
+	for ind := start; ind < len(src); {
+		next := strings.Index(src[ind:], `</`)
+		if next < 0 {
+			break
+		}
+		ind += next
+		if isEndTagFor(src[ind:], self.raw) {
+			end = ind
+			break
+		}
+		ind += 2
+	}
+
+	tag := self.raw
+	self.pos = end
+	self.raw = ``
+	if end == start {
+		return token{}, false
+	}
+
+	data := src[start:end]
+	if self.rcdata {
+		data = unescape(data)
+	} else {
+		data = rawSafe(tag, data)
+	}
+	return token{typ: tokText, data: data}, true
+}
+
+/*
+Ensures that parsed raw text can be rendered again, see `validRaw`. The
+closing tag is never part of the content, but the content may still be
+rejected, for example "<!--<script>" in a script, after which browsers would
+ignore the closing tag. For such content, escapes "</" and "<!--" with a
+backslash, which has the same meaning in JS strings, where such sequences
+usually occur.
+*/
+func rawSafe(tag, val string) string {
+	if rawInvalid(tag, val) == `` {
+		return val
+	}
+	return rawEscaper.Replace(val)
+}
+
+var rawEscaper = strings.NewReplacer(`</`, `<\/`, `<!--`, `<\!--`)
+
+func (self *tokenizer) markup() (token, bool) {
+	src := self.src[self.pos:]
+
+	switch {
+	case strings.HasPrefix(src, `<!--`):
+		return self.comment(), true
+
+	case hasPrefixFold(src, `<!doctype`):
+		data := self.until(len(`<!doctype`), `>`)
+		return token{typ: tokDoctype, data: strings.TrimSpace(data)}, true
+
+	case self.foreign && strings.HasPrefix(src, `<![CDATA[`):
+		return token{typ: tokText, data: self.until(len(`<![CDATA[`), `]]>`)}, true
+
+	case strings.HasPrefix(src, `<!`), strings.HasPrefix(src, `<?`):
+		return token{typ: tokComment, data: self.until(2, `>`)}, true
+
+	case strings.HasPrefix(src, `</>`):
+		self.pos += 3
+		return token{}, false
+
+	case strings.HasPrefix(src, `</`):
+		if !isAsciiLetter(src[2]) {
+			return token{typ: tokComment, data: self.until(2, `>`)}, true
+		}
+		self.pos += 2
+		tok, ok := self.tag()
+		tok.typ = tokEnd
+		tok.attrs = nil
+		tok.self = false
+		return tok, ok
+
+	default:
+		self.pos++
+		tok, ok := self.tag()
+		if ok {
+			tok.typ = tokStart
+			if !tok.self && !self.foreign {
+				if tokRawText.Has(tok.name) {
+					self.raw, self.rcdata = tok.name, false
+				} else if tokRcdata.Has(tok.name) {
+					self.raw, self.rcdata = tok.name, true
+				}
+			}
+		}
+		return tok, ok
+	}
+}
+
+/*
+Comments end with "-->", or "--!>", or the end of input. "<!-->" and "<!--->"
+are empty comments.
+*/
+func (self *tokenizer) comment() token {
+	src := self.src[self.pos+4:]
+
+	switch {
+	case strings.HasPrefix(src, `>`):
+		self.pos += 5
+		return token{typ: tokComment}
+	case strings.HasPrefix(src, `->`):
+		self.pos += 6
+		return token{typ: tokComment}
+	}
+
+	end, size := len(src), 0
+	if ind := strings.Index(src, `-->`); ind >= 0 {
+		end, size = ind, 3
+	}
+	if ind := strings.Index(src[:end], `--!>`); ind >= 0 {
+		end, size = ind, 4
+	}
+
+	self.pos += 4 + end + size
+	return token{typ: tokComment, data: src[:end]}
+}
+
+// Skips the given prefix, then consumes input up to and including the delimiter.
+func (self *tokenizer) until(skip int, delim string) string {
+	src := self.src[self.pos+skip:]
+	ind := strings.Index(src, delim)
+	if ind < 0 {
+		self.pos = len(self.src)
+		return src
+	}
+	self.pos += skip + ind + len(delim)
+	return src[:ind]
+}
+
+/*
+Parses the tag name and attributes, starting after "<" or "</". Returns false
+if the input ends before the tag is closed, in which case the tag is dropped.
+*/
+func (self *tokenizer) tag() (token, bool) {
+	src := self.src
+	ind := self.pos
+	start := ind
+
+	for ind < len(src) && !isTagDelim(src[ind]) {
+		ind++
+	}
+
+	out := token{name: nameSafe(strings.ToLower(src[start:ind]))}
+
+	for {
+		for ind < len(src) && (isSpace(src[ind]) || src[ind] == '/') {
+			if src[ind] == '/' && ind+1 < len(src) && src[ind+1] == '>' {
+				out.self = true
+			}
+			ind++
+		}
+
+		if ind >= len(src) {
+			self.pos = ind
+			return token{}, false
+		}
+		if src[ind] == '>' {
+			self.pos = ind + 1
+			return out, true
+		}
+
+		start = ind
+		ind++
+		for ind < len(src) && !isTagDelim(src[ind]) && src[ind] != '=' {
+			ind++
+		}
+		key := nameSafe(strings.ToLower(src[start:ind]))
+
+		for ind < len(src) && isSpace(src[ind]) {
+			ind++
+		}
+
+		var val string
+		if ind < len(src) && src[ind] == '=' {
+			ind++
+			for ind < len(src) && isSpace(src[ind]) {
+				ind++
+			}
+
+			if ind < len(src) && (src[ind] == '"' || src[ind] == '\'') {
+				quote := src[ind]
+				ind++
+				start = ind
+				for ind < len(src) && src[ind] != quote {
+					ind++
+				}
+				val = src[start:ind]
+				if ind < len(src) {
+					ind++
+				}
+			} else {
+				start = ind
+				for ind < len(src) && !isSpace(src[ind]) && src[ind] != '>' {
+					ind++
+				}
+				val = src[start:ind]
+			}
+		}
+
+		if !attrsHas(out.attrs, key) {
+			out.attrs = append(out.attrs, Attr{key, unescape(val)})
+		}
+	}
+}
+
+func isMarkupStart(src string) bool {
+	if len(src) < 2 || src[0] != '<' {
+		return false
+	}
+	char := src[1]
+	return isAsciiLetter(char) || char == '!' || char == '?' || (char == '/' && len(src) > 2)
+}
+
+func isEndTagFor(src, name string) bool {
+	if len(src) < len(name)+2 || !strings.EqualFold(src[2:2+len(name)], name) {
+		return false
+	}
+	rest := src[2+len(name):]
+	return rest == `` || isTagDelim(rest[0])
+}
+
+func isTagDelim(char byte) bool { return isSpace(char) || char == '/' || char == '>' }
+
+func isSpace(char byte) bool {
+	return char == ' ' || char == '\t' || char == '\n' || char == '\r' || char == '\f'
+}
+
+func hasPrefixFold(src, prefix string) bool {
+	return len(src) >= len(prefix) && strings.EqualFold(src[:len(prefix)], prefix)
+}
+
+/*
+Replaces control characters and the characters ` "<>=` in tag and attribute
+names with U+FFFD, like the HTML tokenizer does for NUL. The tokenizer allows
+the latter in names, for example in `<a"b>` or `<p =x>`, but such names can't
+be rendered, and would otherwise cause a panic with `NameError`; see `Names`.
+*/
+func nameSafe(src string) string {
+	for ind := 0; ind < len(src); ind++ {
+		if isNameUnsafe(rune(src[ind])) {
+			return strings.Map(nameReplace, src)
+		}
+	}
+	return src
+}
+
+func nameReplace(char rune) rune {
+	if isNameUnsafe(char) {
+		return '\uFFFD'
+	}
+	return char
+}
+
+func isNameUnsafe(char rune) bool {
+	return char <= ' ' || char == 0x7f || char == '"' || char == '<' || char == '>' || char == '='
+}
+
+func unescape(src string) string {
+	if strings.IndexByte(src, '&') < 0 && strings.IndexByte(src, 0) < 0 {
+		return src
+	}
+	return strings.ReplaceAll(html.UnescapeString(src), "\x00", "�")
+}
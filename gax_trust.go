@@ -0,0 +1,359 @@
+package gax
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"html/template"
+	"strings"
+	"sync"
+)
+
+/*
+Indicates a trusted URL. When used as an attribute value via `Url.Attr` or `AV`,
+bypasses the sanitization described in `UrlAttrs`, but only for attributes
+whose values are URLs. Elsewhere it's treated like any other string. As a
+child, it's escaped like any other text. Use only for known-good values, never
+for user data. `template.URL` is treated the same way.
+*/
+type Url string
+
+/*
+Returns an attribute with the given name and this value, trusted if the
+attribute is a URL attribute, and treated as a plain string otherwise.
+*/
+func (self Url) Attr(key string) Attr { return attrTrust(key, string(self), ctxUrl) }
+
+/*
+Indicates a trusted "srcset" value: a list of image candidates. When used as an
+attribute value via `Srcset.Attr` or `AV`, bypasses URL sanitization, but only
+for the attributes "srcset" and "imagesrcset". Elsewhere it's treated like any
+other string. As a child, it's escaped like any other text.
+`template.Srcset` is treated the same way.
+*/
+type Srcset string
+
+/*
+Returns an attribute with the given name and this value, trusted if the
+attribute is "srcset" or "imagesrcset", and treated as a plain string
+otherwise.
+*/
+func (self Srcset) Attr(key string) Attr { return attrTrust(key, string(self), ctxSrcset) }
+
+/*
+Indicates trusted CSS. Honored as a child of the "style" element. As a child of
+other raw text elements, causes a panic. As a child of other elements, it's
+escaped like any other text. `template.CSS` is treated the same way.
+
+In attributes, it's treated like any other string. Values of the "style"
+attribute are never sanitized, so there's nothing to opt out of; never use
+untrusted data there, or use `Style`, which checks its values.
+*/
+type Css string
+
+/*
+Indicates trusted JavaScript code. Honored as a child of the "script" element.
+As a child of other raw text elements, causes a panic. As a child of other
+elements, it's escaped like any other text. `template.JS` is treated the same
+way.
+
+In attributes, it's treated like any other string. Values of event handler
+attributes such as "onclick" are never sanitized, so there's nothing to opt
+out of; never use untrusted data there.
+*/
+type Js string
+
+/*
+Indicates a trusted attribute name, which bypasses name validation. Useful for
+names which would otherwise be rejected, such as the special syntax of some
+JS frameworks. The value is treated as usual. May be used as a key in `AV`.
+*/
+type AttrName string
+
+// Returns an attribute with this name and the given value.
+func (self AttrName) Attr(val string) Attr {
+	return Attr{string(self), val}.withMark(markName)
+}
+
+/*
+Short for "attributes from values". Similar to `AP`, but takes pairs of
+arbitrary values rather than strings. Keys must be `string` or `AttrName`.
+Values may be:
+
+	* `string`.
+	* One of the trusted types `Url` and `Srcset`, or their counterparts from
+	  "html/template", which are honored only in attributes of the matching
+	  kind.
+	* `Css`, `Js`, `template.CSS`, `template.JS` or `template.HTML`, which are
+	  treated like strings.
+	* `Flag`, for boolean attributes which may be present, absent, or
+	  unspecified.
+	* `bool`, written as "true" or "false". For attributes listed in `Bool`,
+	  this means present or absent; for others, such as "aria-hidden", the
+	  string is kept.
+	* Integers and floats, written in decimal without an exponent. NaN and
+	  infinities cause a panic.
+	* `time.Time`, written in the format of the HTML "datetime" attribute.
+	* `url.URL` or `*url.URL`, sanitized like strings. Nil is ignored.
+	* `fmt.Stringer`.
+
+Panics if the argument count is not even, or on unsupported types. Symmetric
+with `Attrs.AV`.
+*/
+func AV(pairs ...any) Attrs {
+	if pairs == nil {
+		return nil
+	}
+	return make(Attrs, 0, len(pairs)/2).AV(pairs...)
+}
+
+/*
+Shortcut for appending more attributes from pairs, as if by calling `AV`.
+Panics if the argument count is not even, or on unsupported types.
+*/
+func (self Attrs) AV(pairs ...any) Attrs {
+	if len(pairs)%2 != 0 {
+		panic(PairError{`Attrs.AV`, pairs})
+	}
+
+	for ind := 0; ind < len(pairs); ind += 2 {
+		self = append(self, attrOf(pairs[ind], pairs[ind+1]))
+	}
+	return self
+}
+
+func attrOf(key, val any) Attr {
+	var out Attr
+
+	switch key := key.(type) {
+	case string:
+		out = Attr{key, ``}
+	case AttrName:
+		out = key.Attr(``)
+	default:
+		panic(AttrError{Val: key, Err: fmt.Errorf(`unsupported attribute name type %T`, key)})
+	}
+
+	switch val := val.(type) {
+	case string:
+		return out.Set(val)
+	case template.HTML:
+		return out.Set(string(val))
+	case Url:
+		return out.trust(string(val), ctxUrl)
+	case template.URL:
+		return out.trust(string(val), ctxUrl)
+	case Srcset:
+		return out.trust(string(val), ctxSrcset)
+	case template.Srcset:
+		return out.trust(string(val), ctxSrcset)
+	case Css:
+		return out.Set(string(val))
+	case template.CSS:
+		return out.Set(string(val))
+	case Js:
+		return out.Set(string(val))
+	case template.JS:
+		return out.Set(string(val))
+	case Flag:
+		return out.flag(val)
+	default:
+		return out.typed(val)
+	}
+}
+
+func attrTrust(key, val string, ctx attrCtx) Attr {
+	return Attr{key, ``}.trust(val, ctx)
+}
+
+/*
+Returns a version with the given value, trusted only if the attribute's context
+matches the value's context.
+*/
+func (self Attr) trust(val string, ctx attrCtx) Attr {
+	self = self.Set(val)
+	if attrCtxOf(self.Name()) == ctx {
+		self = self.withMark(self.mark() | markValue)
+	}
+	return self
+}
+
+/*
+Kinds of attribute values which have special rules for sanitization or
+trusted types.
+*/
+type attrCtx byte
+
+const (
+	ctxNone attrCtx = iota
+	ctxUrl
+	ctxSrcset
+	ctxJs
+)
+
+/*
+Context of the given attribute, which affects sanitization. Names are
+case-insensitive, but in practice they're lowercase, and most of them take a
+single set lookup. Other names are lowercased only when they contain uppercase
+letters.
+*/
+func attrCtxOf(key string) attrCtx {
+	if UrlAttrs.Has(key) {
+		if key == `srcset` || key == `imagesrcset` {
+			return ctxSrcset
+		}
+		return ctxUrl
+	}
+	if len(key) >= 2 && lowerAscii(key[0]) == 'o' && lowerAscii(key[1]) == 'n' {
+		return ctxJs
+	}
+	if hasUpper(key) {
+		return attrCtxOf(strings.ToLower(key))
+	}
+	return ctxNone
+}
+
+// Sanitizes an untrusted attribute value according to its context.
+func attrSafe(key, val string) string {
+	switch attrCtxOf(key) {
+	case ctxUrl:
+		if isUrlSafe(val) {
+			return val
+		}
+		return UrlInvalid
+	case ctxSrcset:
+		return srcsetSafe(val)
+	default:
+		return val
+	}
+}
+
+/*
+Writes a trusted child of a raw text element. Honored only for the matching
+element, and rejected elsewhere, since raw text can't be escaped. See `Bui.E`.
+*/
+func (self *Bui) rawTrusted(tag string, val any, text string) {
+	if !strings.EqualFold(tag, rawTarget(val)) {
+		panic(RawError{Tag: tag, Val: val, Path: stateOf(self).path()})
+	}
+	self.NonEscString(text)
+}
+
+// Raw text element for which the given trusted value is intended, if any.
+func rawTarget(val any) string {
+	switch val.(type) {
+	case Js, template.JS:
+		return `script`
+	case Css, template.CSS:
+		return `style`
+	default:
+		return ``
+	}
+}
+
+/*
+Bit flags describing trusted attributes. `markValue` indicates a value trusted
+in the context of this attribute, bypassing contextual sanitization.
+`markName` indicates a name which bypasses validation. `markFlag` indicates a
+boolean attribute created via `Flag`, whose value is "true" or "false".
+
+Attributes may come from untrusted data, so a mark must not be forgeable from
+content alone. A marked attribute carries a signature at the end of its raw
+name (`attr[0]`), see `markSuffix`. The signature is computed with a secret key
+generated for each process, and covers the mark, the name and the value. An
+attribute with a missing or invalid signature is unmarked. Changing the value
+other than via this package, or moving the raw name into another attribute,
+invalidates the signature.
+*/
+const (
+	markValue = 1 << iota
+	markName
+	markFlag
+
+	markAll = markValue | markName | markFlag
+)
+
+/*
+Layout of the signature at the end of the raw name of a marked attribute:
+
+	<name>\x00<mark><tag>
+
+Where "tag" is the truncated HMAC-SHA256 of the mark, the name and the value.
+*/
+const (
+	markTagLen = 16
+	markSuffix = markTagLen + 2
+)
+
+var markKey = func() []byte {
+	out := make([]byte, 32)
+	_, err := rand.Read(out)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}()
+
+var markHashes = sync.Pool{New: func() any { return hmac.New(sha256.New, markKey) }}
+
+// Returns the trust mark of this attribute, or 0.
+func (self Attr) mark() byte {
+	mark, _ := self.marked()
+	return mark
+}
+
+/*
+Returns the trust mark of this attribute, or 0, and the name without the
+signature, like `Attr.Name`.
+*/
+func (self Attr) marked() (byte, string) {
+	name, mark, tag := markSplit(self[0])
+	if mark == 0 || tag != markTag(mark, name, self[1]) {
+		return 0, name
+	}
+	return mark, name
+}
+
+// Returns a version whose name carries exactly the given mark.
+func (self Attr) withMark(mark byte) Attr {
+	name := self.Name()
+	if mark == 0 || name == `` {
+		self[0] = name
+		return self
+	}
+	self[0] = name + "\x00" + string(rune(mark)) + markTag(mark, name, self[1])
+	return self
+}
+
+/*
+Splits the raw name into the name, mark and signature tag. Names without a
+suffix of the right shape are returned as-is, with zero mark. The shape is not
+proof of trust, see `Attr.marked`.
+*/
+func markSplit(val string) (string, byte, string) {
+	ind := len(val) - markSuffix
+	if ind <= 0 || val[ind] != 0 || val[ind+1] == 0 || val[ind+1] > markAll {
+		return val, 0, ``
+	}
+	return val[:ind], val[ind+1], val[ind+2:]
+}
+
+func markTag(mark byte, name, val string) string {
+	mac := markHashes.Get().(hash.Hash)
+	defer markHashes.Put(mac)
+	mac.Reset()
+
+	// The length prefix prevents moving bytes between the name and the value.
+	var head [1 + binary.MaxVarintLen64]byte
+	head[0] = mark
+	size := 1 + binary.PutUvarint(head[1:], uint64(len(name)))
+	mac.Write(head[:size])
+	mac.Write([]byte(name))
+	mac.Write([]byte(val))
+
+	var out [sha256.Size]byte
+	return string(mac.Sum(out[:0])[:markTagLen])
+}
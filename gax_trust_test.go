@@ -0,0 +1,229 @@
+package gax
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAV(t *testing.T) {
+	eq(t, AV(), nil)
+	eq(t, AV(`one`, `two`), AP(`one`, `two`))
+	eq(t, AP(`one`, `two`).AV(`three`, `four`), AP(`one`, `two`, `three`, `four`))
+
+	eqs(
+		t,
+		AV(
+			`href`, Url(`javascript:void(0)`),
+			`src`, template.URL(`javascript:void(0)`),
+			`srcset`, Srcset(`javascript:void(0) 1x`),
+			`imagesrcset`, template.Srcset(`javascript:void(0) 1x`),
+			`style`, Css(`color: red`),
+			`style`, template.CSS(`color: red`),
+			`onclick`, Js(`alert("one")`),
+			`onclick`, template.JS(`alert("one")`),
+			`title`, template.HTML(`<one>`),
+		),
+		` href="javascript:void(0)" src="javascript:void(0)" srcset="javascript:void(0) 1x" imagesrcset="javascript:void(0) 1x" style="color: red" style="color: red" onclick="alert(&quot;one&quot;)" onclick="alert(&quot;one&quot;)" title="<one>"`,
+	)
+}
+
+func TestAV_mismatch(t *testing.T) {
+	eqs(
+		t,
+		AV(
+			`href`, Js(`javascript:alert(1)`),
+			`href`, Css(`javascript:alert(1)`),
+			`src`, Srcset(`javascript:alert(1)`),
+			`srcset`, Url(`javascript:alert(1)`),
+			`href`, template.JS(`javascript:alert(1)`),
+			`href`, template.HTML(`javascript:alert(1)`),
+		),
+		` href="about:invalid#gax" href="about:invalid#gax" src="about:invalid#gax" srcset="about:invalid#gax" href="about:invalid#gax" href="about:invalid#gax"`,
+	)
+
+	eq(t, Url(`one`).Attr(`title`), Attr{`title`, `one`})
+	eq(t, AV(`style`, Js(`one`)), AP(`style`, `one`))
+	eq(t, AV(`onclick`, Css(`one`)), AP(`onclick`, `one`))
+	eq(t, AV(`style`, Css(`one`)), AP(`style`, `one`))
+	eq(t, AV(`onclick`, template.JS(`one`)), AP(`onclick`, `one`))
+	eq(t, Srcset(`one`).Attr(`href`), Attr{`href`, `one`})
+}
+
+func TestAV_panic(t *testing.T) {
+	test := func(msg string, pairs ...any) {
+		t.Helper()
+		defer func() {
+			t.Helper()
+			eq(t, fmt.Sprint(recover()), msg)
+		}()
+		AV(pairs...)
+	}
+
+	test(`[gax] Attrs.AV expects an even amount of args, got []interface {}{"one"}`, `one`)
+	test(`[gax] unsupported attribute name type int`, 10, `one`)
+	test(`[gax] unsupported value type []string for attribute "one"`, `one`, []string{})
+	test(`[gax] can't encode non-finite number NaN for attribute "width"`, `width`, math.NaN())
+	test(`[gax] can't encode non-finite number +Inf for attribute "width"`, `width`, float32(math.Inf(1)))
+}
+
+func TestAV_typed(t *testing.T) {
+	eqs(
+		t,
+		AV(
+			`width`, 10,
+			`height`, int8(-20),
+			`tabindex`, uint16(30),
+			`value`, 0.5,
+			`step`, float32(0.1),
+			`max`, 1e21,
+			`datetime`, time.Date(2024, 1, 2, 3, 4, 5, 600700800, time.UTC),
+			`datetime`, time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone(``, 3600)),
+			`href`, url.URL{Scheme: `https`, Host: `example.com`, Path: `/one two`},
+			`src`, &url.URL{Scheme: `javascript`, Opaque: `alert(1)`},
+			`src`, (*url.URL)(nil),
+			`title`, FlagOn,
+		),
+		` width="10" height="-20" tabindex="30" value="0.5" step="0.1" max="1000000000000000000000" datetime="2024-01-02T03:04:05.6Z" datetime="2024-01-02T03:04:05+01:00" href="https://example.com/one%20two" src="about:invalid#gax" title=""`,
+	)
+
+	eqs(
+		t,
+		AV(
+			`disabled`, true,
+			`checked`, false,
+			`aria-hidden`, true,
+			`aria-expanded`, false,
+			`draggable`, false,
+		),
+		` disabled="" aria-hidden="true" aria-expanded="false" draggable="false"`,
+	)
+
+	eqs(t, AV(`class`, Flag(10)), ``)
+	eqs(t, AV(`data-one`, DialectHtml), ` data-one="html"`)
+}
+
+func TestFlag(t *testing.T) {
+	eq(t, FlagOf(true), FlagOn)
+	eq(t, FlagOf(false), FlagOff)
+	eq(t, FlagUnset.String(), `unset`)
+	eq(t, FlagOn.String(), `on`)
+	eq(t, Flag(10).String(), `Flag(10)`)
+
+	eq(t, FlagUnset.Attr(`disabled`), Attr{})
+	eq(t, FlagOn.Attr(`disabled`).Name(), `disabled`)
+	eq(t, FlagOn.Attr(`disabled`).Value(), `true`)
+	eq(t, FlagOff.Attr(`disabled`).Value(), `false`)
+
+	eqs(t, FlagOn.Attr(`disabled`), ` disabled=""`)
+	eqs(t, FlagOff.Attr(`disabled`), ``)
+	eqs(t, FlagOn.Attr(`one-two`), ` one-two=""`)
+	eqs(t, FlagOff.Attr(`one-two`), ``)
+	eqs(t, FlagOn.Attr(`one-two`).Set(`false`), ` one-two="false"`)
+	eqs(t, AV(AttrName(`:one`), FlagOn), ` :one=""`)
+
+	eqs(
+		t,
+		(&Ctx{Xml: true}).F(E(`one`, A(FlagOn.Attr(`two`), FlagOff.Attr(`three`)))),
+		`<one two="two"/>`,
+	)
+	eqs(
+		t,
+		(&Ctx{Minify: true}).F(E(`one-two`, A(FlagOn.Attr(`three`), FlagOff.Attr(`four`)))),
+		`<one-two three></one-two>`,
+	)
+}
+
+func TestAttrName(t *testing.T) {
+	attr := AttrName(`@click`).Attr(`open = true`)
+	eq(t, attr.Name(), `@click`)
+	eqs(t, attr, ` @click="open = true"`)
+	eqs(t, AttrName(`x on:click`).Attr(``), ` x on:click=""`)
+	eqs(t, AV(AttrName(`:href`), `javascript:alert(1)`), ` :href="javascript:alert(1)"`)
+	eqs(t, AV(AttrName(`href`), Url(`javascript:void(0)`)), ` href="javascript:void(0)"`)
+	eqs(t, AV(AttrName(`href`), `javascript:alert(1)`), ` href="about:invalid#gax"`)
+
+	eq(t, attr.Set(`two`).Name(), `@click`)
+	eqs(t, attr.Set(`two`), ` @click="two"`)
+
+	defer func() { eq(t, fmt.Sprint(recover()), `[gax] invalid attribute name "x on:click"`) }()
+	_ = Attr{`x on:click`, ``}.String()
+}
+
+func TestAttr_mark_spoofing(t *testing.T) {
+	eqs(t, AP("\x01href", `javascript:alert(1)`), " \x01href=\"javascript:alert(1)\"")
+	eqs(t, AP(`href`, "\x01javascript:alert(1)"), ` href="about:invalid#gax"`)
+	eqs(t, AP("\x04hidden", `false`), " \x04hidden=\"false\"")
+
+	trusted := AV(`href`, Url(`javascript:void(0)`))[0]
+	eq(t, trusted.Name(), `href`)
+	eq(t, trusted.Value(), `javascript:void(0)`)
+	eqs(t, trusted, ` href="javascript:void(0)"`)
+	eqs(t, Attr{trusted.Name(), `javascript:alert(1)`}, ` href="about:invalid#gax"`)
+	eqs(t, Attr{trusted[0], `javascript:alert(1)`}, ` href="about:invalid#gax"`)
+	eqs(t, Attr{trusted[0], trusted[1]}, ` href="javascript:void(0)"`)
+	eqs(t, trusted.SetName(`href`), ` href="about:invalid#gax"`)
+	eqs(t, trusted.Set(`javascript:alert(1)`), ` href="about:invalid#gax"`)
+
+	forged := Attr{`href` + "\x00\x01" + strings.Repeat("\x00", markTagLen), `javascript:alert(1)`}
+	eq(t, forged.Name(), `href`)
+	eqs(t, forged, ` href="about:invalid#gax"`)
+
+	name := AttrName(`x on:click`).Attr(`one`)
+	eqs(t, name, ` x on:click="one"`)
+	eqs(t, name.Set(`two`), ` x on:click="two"`)
+
+	func() {
+		defer func() { eq(t, fmt.Sprint(recover()), `[gax] invalid attribute name "x on:click"`) }()
+		_ = Attr{name[0], `two`}.String()
+	}()
+
+	func() {
+		defer func() { eq(t, fmt.Sprint(recover()), `[gax] invalid attribute name "\x02onmouseover=alert(1) x"`) }()
+		_ = F(E(`div`, AP("\x02onmouseover=alert(1) x", `y`)))
+	}()
+
+	func() {
+		defer func() { eq(t, fmt.Sprint(recover()), `[gax] invalid attribute name "\x02onclick" in element "div" (html)`) }()
+		_ = (&Ctx{Dialect: DialectHtml}).F(E(`div`, AP("\x02onclick", `y`)))
+	}()
+}
+
+func TestBui_Child_trusted(t *testing.T) {
+	test := childTest(t)
+
+	test(template.HTML(`<one>`), `<one>`)
+	test(Url(`<one>`), `&lt;one&gt;`)
+	test(template.URL(`<one>`), `&lt;one&gt;`)
+	test(Css(`<one>`), `&lt;one&gt;`)
+	test(template.CSS(`<one>`), `&lt;one&gt;`)
+	test(Js(`<one>`), `&lt;one&gt;`)
+	test(template.JS(`<one>`), `&lt;one&gt;`)
+	test(Srcset(`<one>`), `&lt;one&gt;`)
+}
+
+func TestBui_E_raw_trusted(t *testing.T) {
+	eqs(t, E(`script`, nil, Js(`a && b`)), `<script>a && b</script>`)
+	eqs(t, E(`script`, nil, template.JS(`a && b`)), `<script>a && b</script>`)
+	eqs(t, E(`style`, nil, Css(`a > b {}`)), `<style>a > b {}</style>`)
+	eqs(t, E(`style`, nil, template.CSS(`a > b {}`)), `<style>a > b {}</style>`)
+
+	fail := func(tag string, val any, msg string) {
+		t.Helper()
+		defer func() {
+			t.Helper()
+			eq(t, fmt.Sprint(recover()), msg)
+		}()
+		F(E(tag, nil, val))
+	}
+
+	fail(`style`, Js(``), `[gax] can't embed content intended for "script" in raw text element "style" at "style"`)
+	fail(`script`, template.CSS(``), `[gax] can't embed content intended for "style" in raw text element "script" at "script"`)
+	fail(`script`, Url(``), `[gax] can't embed gax.Url in raw text element "script" at "script"`)
+	fail(`script`, template.HTML(``), `[gax] can't embed template.HTML in raw text element "script" at "script"`)
+	fail(`script`, Js(`</script>`), `[gax] can't embed "</script" in raw text element "script" at "script"`)
+}
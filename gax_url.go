@@ -0,0 +1,162 @@
+package gax
+
+import "strings"
+
+/*
+Replacement for unsafe URLs in attributes listed in `UrlAttrs`. Points
+nowhere and can't execute anything.
+*/
+const UrlInvalid = `about:invalid#gax`
+
+/*
+Set of attributes whose values are URLs or lists of URLs. When encoding such
+attributes, the values are checked against `UrlSchemes`, and unsafe values are
+replaced with `UrlInvalid`. Can be modified via `UrlAttrs.Add` and
+`UrlAttrs.Del`. To bypass this for known-good values, see `Url` and
+`Srcset`.
+*/
+var UrlAttrs = newStringSet(
+	`action`, `archive`, `background`, `cite`, `classid`, `codebase`, `data`,
+	`dynsrc`, `formaction`, `href`, `icon`, `imagesrcset`, `longdesc`,
+	`lowsrc`, `manifest`, `poster`, `profile`, `src`, `srcset`, `usemap`,
+	`xlink:href`, `xml:base`,
+)
+
+/*
+Set of URL schemes allowed in attributes listed in `UrlAttrs`. Relative URLs,
+which don't have a scheme, are always allowed. Can be modified via
+`UrlSchemes.Add` and `UrlSchemes.Del`. Entries must be lowercase.
+
+The "data" scheme is special-cased: only media types which can't execute
+scripts are allowed, such as raster images, audio, video, fonts and plain
+text.
+*/
+var UrlSchemes = newStringSet(`data`, `http`, `https`, `mailto`, `tel`)
+
+/*
+Sanitizes each URL in a "srcset" list, preserving the descriptors. Reference:
+
+	https://html.spec.whatwg.org/multipage/images.html#srcset-attributes
+*/
+func srcsetSafe(src string) string {
+	var buf []byte
+
+	for ind, val := range strings.Split(src, `,`) {
+		if ind > 0 {
+			buf = append(buf, `,`...)
+		}
+
+		trim := strings.TrimLeft(val, " \t\n\r\f")
+		buf = append(buf, val[:len(val)-len(trim)]...)
+
+		url, desc := trim, ``
+		if ind := strings.IndexAny(trim, " \t\n\r\f"); ind >= 0 {
+			url, desc = trim[:ind], trim[ind:]
+		}
+
+		if isUrlSafe(url) {
+			buf = append(buf, url...)
+		} else {
+			buf = append(buf, UrlInvalid...)
+		}
+		buf = append(buf, desc...)
+	}
+	return string(buf)
+}
+
+func isUrlSafe(val string) bool {
+	scheme, rest := urlScheme(val)
+	if scheme == `` {
+		return true
+	}
+	if !UrlSchemes.Has(scheme) {
+		return false
+	}
+	if scheme == `data` {
+		return isDataUrlSafe(rest)
+	}
+	return true
+}
+
+/*
+Extracts the scheme, lowercased, and the rest of the URL, mimicking how browsers
+parse URLs: leading control characters and spaces are ignored, and tabs and
+newlines are ignored anywhere. Returns an empty scheme for relative URLs.
+Reference:
+
+	https://url.spec.whatwg.org/#concept-basic-url-parser
+*/
+func urlScheme(src string) (string, string) {
+	for len(src) > 0 && src[0] <= ' ' {
+		src = src[1:]
+	}
+
+	size := 0
+	plain := true
+
+	for ind := 0; ind < len(src); ind++ {
+		char := src[ind]
+
+		switch {
+		case char == '\t' || char == '\n' || char == '\r':
+			plain = false
+
+		case char == ':':
+			if size == 0 {
+				return ``, src
+			}
+			if plain {
+				return src[:ind], src[ind+1:]
+			}
+			return urlSchemeFold(src[:ind]), src[ind+1:]
+
+		case isAsciiLetter(char):
+			plain = plain && char >= 'a'
+			size++
+
+		case size > 0 && (isAsciiDigit(char) || char == '+' || char == '-' || char == '.'):
+			size++
+
+		default:
+			return ``, src
+		}
+	}
+	return ``, src
+}
+
+// Lowercases the scheme, removing tabs and newlines. See `urlScheme`.
+func urlSchemeFold(src string) string {
+	buf := make([]byte, 0, len(src))
+	for ind := 0; ind < len(src); ind++ {
+		switch char := src[ind]; char {
+		case '\t', '\n', '\r':
+		default:
+			buf = append(buf, lowerAscii(char))
+		}
+	}
+	return string(buf)
+}
+
+/*
+Allows only media types which can't execute scripts, regardless of where
+they're used. Notably excludes HTML and SVG.
+*/
+func isDataUrlSafe(src string) bool {
+	typ := src
+	if ind := strings.IndexAny(typ, `;,`); ind >= 0 {
+		typ = typ[:ind]
+	}
+	typ = strings.ToLower(strings.TrimSpace(typ))
+
+	switch {
+	case typ == ``, typ == `text/plain`:
+		return true
+	case typ == `image/svg+xml`:
+		return false
+	default:
+		return strings.HasPrefix(typ, `image/`) ||
+			strings.HasPrefix(typ, `audio/`) ||
+			strings.HasPrefix(typ, `video/`) ||
+			strings.HasPrefix(typ, `font/`)
+	}
+}
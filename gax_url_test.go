@@ -0,0 +1,63 @@
+package gax
+
+import "testing"
+
+func TestAttr_url_sanitization(t *testing.T) {
+	test := func(src Attr, exp string) {
+		t.Helper()
+		eqs(t, src, exp)
+	}
+
+	test(Attr{`href`, `/one/two?three#four`}, ` href="/one/two?three#four"`)
+	test(Attr{`href`, `one:two`}, ` href="about:invalid#gax"`)
+	test(Attr{`href`, `https://example.com`}, ` href="https://example.com"`)
+	test(Attr{`href`, `HTTPS://example.com`}, ` href="HTTPS://example.com"`)
+	test(Attr{`href`, `mailto:one@example.com`}, ` href="mailto:one@example.com"`)
+	test(Attr{`href`, `javascript:alert(1)`}, ` href="about:invalid#gax"`)
+	test(Attr{`href`, `JavaScript:alert(1)`}, ` href="about:invalid#gax"`)
+	test(Attr{`href`, " \x01java\tscr\nipt:alert(1)"}, ` href="about:invalid#gax"`)
+	test(Attr{`href`, `vbscript:msgbox`}, ` href="about:invalid#gax"`)
+	test(Attr{`href`, `/path:with:colons`}, ` href="/path:with:colons"`)
+	test(Attr{`href`, `./javascript:alert(1)`}, ` href="./javascript:alert(1)"`)
+	test(Attr{`HREF`, `javascript:alert(1)`}, ` HREF="about:invalid#gax"`)
+	test(Attr{`hRef`, `JAVASCRIPT:alert(1)`}, ` hRef="about:invalid#gax"`)
+	test(Attr{`SrcSet`, `javascript:alert(1) 1x`}, ` SrcSet="about:invalid#gax 1x"`)
+	test(Attr{`src`, `javascript:alert(1)`}, ` src="about:invalid#gax"`)
+	test(Attr{`action`, `javascript:alert(1)`}, ` action="about:invalid#gax"`)
+	test(Attr{`formaction`, `javascript:alert(1)`}, ` formaction="about:invalid#gax"`)
+	test(Attr{`poster`, `javascript:alert(1)`}, ` poster="about:invalid#gax"`)
+	test(Attr{`xlink:href`, `javascript:alert(1)`}, ` xlink:href="about:invalid#gax"`)
+	test(Attr{`title`, `javascript:alert(1)`}, ` title="javascript:alert(1)"`)
+
+	test(Attr{`href`, `data:;base64,=`}, ` href="data:;base64,="`)
+	test(Attr{`src`, `data:image/png;base64,AAAA`}, ` src="data:image/png;base64,AAAA"`)
+	test(Attr{`src`, `data:text/plain,hello`}, ` src="data:text/plain,hello"`)
+	test(Attr{`src`, `data:text/html,<script>alert(1)</script>`}, ` src="about:invalid#gax"`)
+	test(Attr{`src`, `data:image/svg+xml,<svg></svg>`}, ` src="about:invalid#gax"`)
+
+	test(
+		Attr{`srcset`, `/one.png 1x, javascript:alert(1) 2x,data:image/png;base64,AAAA 3x`},
+		` srcset="/one.png 1x, about:invalid#gax 2x,data:image/png;base64,AAAA 3x"`,
+	)
+}
+
+func TestUrlSchemes(t *testing.T) {
+	UrlSchemes.Add(`custom`)
+	defer UrlSchemes.Del(`custom`)
+
+	eqs(t, Attr{`href`, `custom:one`}, ` href="custom:one"`)
+}
+
+func TestUrl_Attr(t *testing.T) {
+	attr := Url(`javascript:void(0)`).Attr(`href`)
+
+	eq(t, attr.Name(), `href`)
+	eq(t, attr.Value(), `javascript:void(0)`)
+	eqs(t, attr, ` href="javascript:void(0)"`)
+	eqs(t, attr.Set(`javascript:alert(1)`), ` href="about:invalid#gax"`)
+	eqs(t, attr.Add(`two`), ` href="about:invalid#gax"`)
+	eqs(t, Url(`javascript:"one"`).Attr(`href`), ` href="javascript:&quot;one&quot;"`)
+
+	eqs(t, A(attr).Set(`href`, `javascript:alert(1)`), ` href="about:invalid#gax"`)
+	eqs(t, F(E(`a`, A(attr), Url(`<one>`))), `<a href="javascript:void(0)">&lt;one&gt;</a>`)
+}
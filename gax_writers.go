@@ -136,3 +136,54 @@ func (self *TextWri) WriteRune(val rune) (int, error) {
 
 // Similar to `strings.Builder.String`. Free cast with no allocation.
 func (self TextWri) String() string { return bytesString(self) }
+
+/*
+Short for "XML writer". Mostly for internal use. Writes text or attribute
+values for XML and XHTML, without enclosing quotes, escaping as necessary.
+Unlike `TextWri` and `AttrWri`, this escapes both kinds of quotes, and avoids
+HTML-only entities such as "&nbsp;", which are undefined in XML. Used when
+rendering with `Ctx.Xml`.
+*/
+type XmlWri []byte
+
+/*
+Implement `io.Writer`. Similar to `strings.Builder.Write`, but escapes special
+chars. Technically not compliant with `io.Writer`: the returned count of
+written bytes may exceed the size of the provided chunk.
+*/
+func (self *XmlWri) Write(val []byte) (int, error) {
+	return self.WriteString(bytesString(val))
+}
+
+// Implement `io.StringWriter`. Similar to `strings.Builder.WriteString`, but
+// escapes special chars.
+func (self *XmlWri) WriteString(val string) (size int, _ error) {
+	for _, char := range val {
+		delta, _ := self.WriteRune(char)
+		size += delta
+	}
+	return
+}
+
+// Similar to `strings.Builder.WriteRune`, but escapes special chars.
+func (self *XmlWri) WriteRune(val rune) (int, error) {
+	wri := (*NonEscWri)(self)
+
+	switch val {
+	case '&':
+		return wri.WriteString(`&amp;`)
+	case '<':
+		return wri.WriteString(`&lt;`)
+	case '>':
+		return wri.WriteString(`&gt;`)
+	case '"':
+		return wri.WriteString(`&quot;`)
+	case '\'':
+		return wri.WriteString(`&#39;`)
+	default:
+		return wri.WriteRune(val)
+	}
+}
+
+// Similar to `strings.Builder.String`. Free cast with no allocation.
+func (self XmlWri) String() string { return bytesString(self) }